@@ -0,0 +1,150 @@
+package vagrant_go
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/palantir/stacktrace"
+	"io"
+	"os"
+	"os/exec"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+)
+
+const redactedEnvValue = "[REDACTED]"
+
+// NOTE: Env keys containing any of these, case-insensitively, are considered secret.
+var secretEnvKeyParts = []string{"TOKEN", "SECRET", "PASSWORD", "PASSWD", "KEY", "CREDENTIAL", "AUTH"}
+
+// AuditRecord is a record of a single vagrant invocation, written to `Config.AuditSink` as a line of JSON.
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+	// User is the name of the user running the process.
+	User             string   `json:"user"`
+	Binary           string   `json:"binary"`
+	Args             []string `json:"args"`
+	WorkingDirectory string   `json:"working_directory"`
+	// Env has values of secret-looking keys redacted.
+	Env []string `json:"env"`
+	// ExitStatus is -1, if the command did not exit on its own, e.g. it failed to start or was killed.
+	ExitStatus      int     `json:"exit_status"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Error           string  `json:"error,omitempty"`
+}
+
+type auditLog struct {
+	mutex sync.Mutex
+	sink  io.Writer
+	user  string
+}
+
+func newAuditLog(sink io.Writer) *auditLog {
+	return &auditLog{
+		sink: sink,
+		user: currentUserName(),
+	}
+}
+
+// middleware records every invocation after it's done. Failing to write a record fails the invocation, unless it
+// failed already.
+func (l *auditLog) middleware(next CommandHandler) CommandHandler {
+	return func(ctx context.Context, spec *CommandSpec) ([]byte, error) {
+		startedAt := time.Now()
+
+		output, err := next(ctx, spec)
+
+		record := &AuditRecord{
+			Time:             startedAt.UTC(),
+			User:             l.user,
+			Binary:           spec.Binary,
+			Args:             spec.Args,
+			WorkingDirectory: spec.WorkingDirectory,
+			Env:              redactEnv(spec.Env),
+			ExitStatus:       exitStatus(err),
+			DurationSeconds:  time.Since(startedAt).Seconds(),
+		}
+
+		if err != nil {
+			record.Error = err.Error()
+		}
+
+		writeErr := l.write(record)
+		if writeErr != nil && err == nil {
+			return output, writeErr
+		}
+
+		return output, err
+	}
+}
+
+func (l *auditLog) write(record *AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to encode audit record")
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	// NOTE: The record is written with a single call, so that records of concurrent processes don't interleave
+	_, err = l.sink.Write(append(line, '\n'))
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to write audit record")
+	}
+
+	return nil
+}
+
+func exitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+
+	return -1
+}
+
+// redactEnv returns a copy of `env` in `key=value` form, with values of secret-looking keys redacted.
+func redactEnv(env []string) []string {
+	redactedEnv := make([]string, 0, len(env))
+
+	for _, entry := range env {
+		key := strings.SplitN(entry, "=", 2)[0]
+
+		if isSecretEnvKey(key) {
+			entry = key + "=" + redactedEnvValue
+		}
+
+		redactedEnv = append(redactedEnv, entry)
+	}
+
+	return redactedEnv
+}
+
+func isSecretEnvKey(key string) bool {
+	upperKey := strings.ToUpper(key)
+
+	for _, part := range secretEnvKeyParts {
+		if strings.Contains(upperKey, part) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func currentUserName() string {
+	currentUser, err := user.Current()
+	if err == nil {
+		return currentUser.Username
+	}
+
+	return os.Getenv("USER")
+}
@@ -0,0 +1,175 @@
+package vagrant_go
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os/exec"
+	"testing"
+)
+
+func auditRecords(t *testing.T, sink *bytes.Buffer) []*AuditRecord {
+	records := []*AuditRecord{}
+
+	scanner := bufio.NewScanner(sink)
+	for scanner.Scan() {
+		record := &AuditRecord{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), record))
+
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func TestClient_AuditSink(t *testing.T) {
+	t.Run(
+		"with audit sink, it appends a record per invocation of box and lifecycle operations",
+		func(t *testing.T) {
+			t.Parallel()
+
+			sink := &bytes.Buffer{}
+
+			client, err := NewClient(
+				&Config{AuditSink: sink},
+				func(cmd string, args ...string) ([]byte, error) {
+					if args[len(args)-1] == "halt" {
+						return nil, errors.New("fake error")
+					}
+
+					return nil, nil
+				},
+				emptyLookPathFunc,
+			)
+			require.NoError(t, err)
+
+			fakeOsExecutor := &fakeOsExecutor{}
+			fakeOsExecutor.On("Getwd").Return("/tmp/example", nil)
+			fakeOsExecutor.On("Environ").Return([]string{})
+			client.osExecutor = fakeOsExecutor
+
+			_, err = client.Box.List()
+			require.NoError(t, err)
+
+			err = client.Global.Halt(DefaultHaltOptions())
+			require.Error(t, err)
+
+			records := auditRecords(t, sink)
+			require.Len(t, records, 2)
+
+			assert.Equal(t, "vagrant", records[0].Binary)
+			assert.Equal(t, []string{"--machine-readable", "box", "list"}, records[0].Args)
+			assert.Equal(t, currentUserName(), records[0].User)
+			assert.Equal(t, "/tmp/example", records[0].WorkingDirectory)
+			assert.False(t, records[0].Time.IsZero())
+			assert.Equal(t, 0, records[0].ExitStatus)
+			assert.Empty(t, records[0].Error)
+
+			assert.Equal(t, []string{"--machine-readable", "halt"}, records[1].Args)
+			assert.Equal(t, -1, records[1].ExitStatus)
+			assert.Equal(t, "fake error", records[1].Error)
+		},
+	)
+
+	t.Run(
+		"with current working directory not known, it fails without executing the command",
+		func(t *testing.T) {
+			t.Parallel()
+
+			sink := &bytes.Buffer{}
+
+			client, err := NewClient(
+				&Config{AuditSink: sink},
+				func(cmd string, args ...string) ([]byte, error) {
+					t.Fatalf("unexpected execution of `%s %v`", cmd, args)
+					return nil, nil
+				},
+				emptyLookPathFunc,
+			)
+			require.NoError(t, err)
+
+			fakeOsExecutor := &fakeOsExecutor{}
+			fakeOsExecutor.On("Getwd").Return("", errors.New("fake error"))
+			client.osExecutor = fakeOsExecutor
+
+			_, err = client.Box.List()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "failed to get current working directory")
+			assert.Empty(t, sink.String())
+		},
+	)
+
+	t.Run(
+		"with audit sink failing to write, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			client.middleware = []Middleware{
+				newAuditLog(&failingWriter{}).middleware,
+			}
+
+			err := client.Global.Halt(DefaultHaltOptions())
+			assert.Error(t, err)
+		},
+	)
+}
+
+type failingWriter struct{}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("fake error")
+}
+
+func TestRedactEnv(t *testing.T) {
+	t.Parallel()
+
+	env := []string{
+		"HOME=/root",
+		"VAGRANT_CLOUD_TOKEN=secret",
+		"AWS_SECRET_ACCESS_KEY=secret",
+		"db_password=secret",
+		"EMPTY",
+	}
+
+	assert.Equal(t, []string{
+		"HOME=/root",
+		"VAGRANT_CLOUD_TOKEN=[REDACTED]",
+		"AWS_SECRET_ACCESS_KEY=[REDACTED]",
+		"db_password=[REDACTED]",
+		"EMPTY",
+	}, redactEnv(env))
+}
+
+func TestExitStatus(t *testing.T) {
+	t.Run(
+		"with no error, it returns 0",
+		func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, 0, exitStatus(nil))
+		},
+	)
+
+	t.Run(
+		"with exit error, it returns the exit code",
+		func(t *testing.T) {
+			t.Parallel()
+
+			err := exec.Command("sh", "-c", "exit 3").Run()
+			assert.Equal(t, 3, exitStatus(err))
+		},
+	)
+
+	t.Run(
+		"with other error, it returns -1",
+		func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, -1, exitStatus(errors.New("fake error")))
+		},
+	)
+}
@@ -9,6 +9,11 @@ var _ BoxAPI = (*boxAPI)(nil)
 
 type BoxAPI interface {
 	List() ([]*Box, error)
+	// Add adds box `name`, which is a name in Vagrant Cloud, a URL or a path to a box file.
+	Add(name string, options *BoxAddOptions) error
+	// PlanList returns the command `List` would execute, without executing anything.
+	PlanList() (*CommandSpec, error)
+	PlanAdd(name string, options *BoxAddOptions) (*CommandSpec, error)
 }
 
 type boxAPI struct {
@@ -22,11 +27,110 @@ type Box struct {
 }
 
 func (api *boxAPI) List() ([]*Box, error) {
-	outputLines, err := api.client.executeVagrantCommand("box", "list")
+	err := api.client.checkBoxPolicy("box list")
+	if err != nil {
+		return nil, err
+	}
+
+	outputLines, err := api.client.withRetry(func() ([]*vagrantOutputLine, error) {
+		return api.executeVagrantCommand(boxListArgs()...)
+	})
 	if err != nil {
 		return nil, stacktrace.Propagate(err, "command execution failed")
 	}
 
+	return boxesFromOutputLines(outputLines), nil
+}
+
+func boxListArgs() []string {
+	return []string{
+		"box",
+		"list",
+	}
+}
+
+type BoxAddOptions struct {
+	Provider string
+	Version  string
+	// Force replaces the box, if it's added already.
+	Force bool
+	// Clean removes cached partial downloads before downloading.
+	Clean bool
+}
+
+func DefaultBoxAddOptions() *BoxAddOptions {
+	return &BoxAddOptions{
+		Provider: "",
+		Version:  "",
+		Force:    false,
+		Clean:    false,
+	}
+}
+
+func (api *boxAPI) Add(name string, options *BoxAddOptions) error {
+	err := api.client.checkBoxPolicy("box add")
+	if err != nil {
+		return err
+	}
+
+	args, err := boxAddArgs(name, options)
+	if err != nil {
+		return err
+	}
+
+	_, err = api.client.withRetry(func() ([]*vagrantOutputLine, error) {
+		return api.executeVagrantCommand(args...)
+	})
+	if err != nil {
+		return stacktrace.Propagate(err, "command execution failed")
+	}
+
+	return nil
+}
+
+func boxAddArgs(name string, options *BoxAddOptions) ([]string, error) {
+	if len(name) == 0 {
+		return nil, stacktrace.NewError("`name` must be set")
+	}
+
+	args := []string{
+		"box",
+		"add",
+	}
+
+	if len(options.Provider) > 0 {
+		args = append(args, "--provider", options.Provider)
+	}
+
+	if len(options.Version) > 0 {
+		args = append(args, "--box-version", options.Version)
+	}
+
+	if options.Force {
+		args = append(args, "--force")
+	}
+
+	if options.Clean {
+		args = append(args, "--clean")
+	}
+
+	return append(args, name), nil
+}
+
+// executeVagrantCommand executes given box command holding the lock of `VAGRANT_HOME`, if `Config.Lock` is set.
+func (api *boxAPI) executeVagrantCommand(args ...string) ([]*vagrantOutputLine, error) {
+	var outputLines []*vagrantOutputLine
+
+	err := api.client.withVagrantHomeLock(func() error {
+		var err error
+		outputLines, err = api.client.executeVagrantCommand(args...)
+		return err
+	})
+
+	return outputLines, err
+}
+
+func boxesFromOutputLines(outputLines []*vagrantOutputLine) []*Box {
 	var name, provider, version string
 
 	// NOTE: Use 0 element slice in case there's nothing to return
@@ -34,6 +138,10 @@ func (api *boxAPI) List() ([]*Box, error) {
 	boxes := []*Box{}
 
 	for _, line := range outputLines {
+		if len(line.data) < 1 {
+			continue
+		}
+
 		switch line.kind {
 		case "box-name":
 			if len(name) > 0 {
@@ -69,5 +177,5 @@ func (api *boxAPI) List() ([]*Box, error) {
 		)
 	}
 
-	return boxes, nil
+	return boxes
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func TestBoxApiList(t *testing.T) {
@@ -136,3 +137,79 @@ func TestBoxApiList(t *testing.T) {
 		},
 	)
 }
+
+func TestBoxApiAdd(t *testing.T) {
+	t.Run(
+		"with options, it executes command with them",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+
+			isCommandRunCalled := false
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(
+					t,
+					[]string{
+						"--machine-readable",
+						"box", "add",
+						"--provider", "libvirt",
+						"--box-version", "1.2.3",
+						"--force",
+						"--clean",
+						"debian/buster64",
+					},
+					args,
+				)
+
+				isCommandRunCalled = true
+				return []byte{}, nil
+			}
+
+			options := DefaultBoxAddOptions()
+			options.Provider = "libvirt"
+			options.Version = "1.2.3"
+			options.Force = true
+			options.Clean = true
+
+			err := client.Box.Add("debian/buster64", options)
+			require.NoError(t, err)
+			assert.True(t, isCommandRunCalled)
+		},
+	)
+
+	t.Run(
+		"with download failing once and retry policy, it retries",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			client.Config.Retry = DefaultRetryPolicy()
+			client.sleepFunc = func(d time.Duration) {}
+
+			calls := 0
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				calls++
+				if calls == 1 {
+					return []byte(`1546430404,,error-exit,Vagrant::Errors::DownloaderError,Timeout`), errors.New("fake error")
+				}
+
+				return []byte{}, nil
+			}
+
+			err := client.Box.Add("debian/buster64", DefaultBoxAddOptions())
+			require.NoError(t, err)
+			assert.Equal(t, 2, calls)
+		},
+	)
+
+	t.Run(
+		"with no name, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			err := emptyTestClient(t).Box.Add("", DefaultBoxAddOptions())
+			assert.Error(t, err)
+		},
+	)
+}
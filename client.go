@@ -1,16 +1,28 @@
 package vagrant_go
 
 import (
+	"bytes"
+	"context"
 	"github.com/palantir/stacktrace"
-	"strings"
+	"math/rand"
+	"sync"
+	"time"
 )
 
 type Client struct {
 	Config         *Config
 	commandRunFunc func(cmd string, args ...string) ([]byte, error)
-	osExecutor     OsExecutor
-	Box            BoxAPI
-	Global         GlobalAPI
+	// commandRunContextFunc is used instead of `commandRunFunc`, when set. It's set only when no `commandRunFunc`
+	// is given to `NewClient`, since a given one can't be cancelled.
+	commandRunContextFunc func(ctx context.Context, spec *CommandSpec) ([]byte, error)
+	osExecutor            OsExecutor
+	middleware            []Middleware
+	sleepFunc             func(d time.Duration)
+	randomFunc            func() float64
+	versionMutex          sync.Mutex
+	version               *Version
+	Box                   BoxAPI
+	Global                GlobalAPI
 }
 
 func NewClient(
@@ -24,6 +36,27 @@ func NewClient(
 		clientConfig.BinaryName = config.BinaryName
 	}
 
+	if config != nil && len(config.Middleware) > 0 {
+		clientConfig.Middleware = config.Middleware
+	}
+
+	if config != nil {
+		clientConfig.AuditSink = config.AuditSink
+		clientConfig.Policy = config.Policy
+		clientConfig.Metrics = config.Metrics
+		clientConfig.Tracer = config.Tracer
+		clientConfig.Retry = config.Retry
+		clientConfig.Lock = config.Lock
+		clientConfig.ConcurrencyLimit = config.ConcurrencyLimit
+	}
+
+	if clientConfig.ConcurrencyLimit != nil {
+		err := clientConfig.ConcurrencyLimit.validate()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	clientLookPathFunc := realLookPathFunc
 	if lookPathFunc != nil {
 		clientLookPathFunc = lookPathFunc
@@ -39,13 +72,32 @@ func NewClient(
 	}
 
 	clientCommandRunFunc := realCommandRunFunc
+	clientCommandRunContextFunc := realCommandRunContextFunc
 	if commandRunFunc != nil {
 		clientCommandRunFunc = commandRunFunc
+		clientCommandRunContextFunc = nil
 	}
 
 	client := &Client{
-		Config:         clientConfig,
-		commandRunFunc: clientCommandRunFunc,
+		Config:                clientConfig,
+		commandRunFunc:        clientCommandRunFunc,
+		commandRunContextFunc: clientCommandRunContextFunc,
+		osExecutor:            &osExecutor{},
+		middleware:            append([]Middleware{}, clientConfig.Middleware...),
+		sleepFunc:             time.Sleep,
+		randomFunc:            rand.Float64,
+	}
+
+	if clientConfig.Metrics != nil {
+		client.middleware = append(client.middleware, metricsMiddleware(clientConfig.Metrics))
+	}
+
+	if clientConfig.Tracer != nil {
+		client.middleware = append(client.middleware, tracingMiddleware(clientConfig.Tracer))
+	}
+
+	if clientConfig.AuditSink != nil {
+		client.middleware = append(client.middleware, newAuditLog(clientConfig.AuditSink).middleware)
 	}
 
 	client.Box = &boxAPI{
@@ -54,7 +106,7 @@ func NewClient(
 
 	client.Global = &globalAPI{
 		client:     client,
-		osExecutor: &osExecutor{},
+		osExecutor: client.osExecutor,
 	}
 
 	return client, nil
@@ -69,27 +121,130 @@ func (c *Client) executeVagrantCommand(args ...string) ([]*vagrantOutputLine, er
 		cmdArgs = append(cmdArgs, arg)
 	}
 
-	output, err := c.commandRunFunc(c.Config.BinaryName, cmdArgs...)
-	return c.parseMachineReadableOutput(string(output)), err
+	ctx := withParsedOutput(context.Background())
 
+	output, err := c.runCommand(ctx, cmdArgs...)
+	return parseCommandOutput(ctx, output, err, true)
 }
 
-func (c *Client) parseMachineReadableOutput(output string) []*vagrantOutputLine {
-	vagrantOutputLines := []*vagrantOutputLine{}
+// executeVagrantCommandWithAllLines is like `executeVagrantCommand`, but keeps the `metadata`, `ui` and `action` lines.
+func (c *Client) executeVagrantCommandWithAllLines(args ...string) ([]*vagrantOutputLine, error) {
+	cmdArgs := append([]string{"--machine-readable"}, args...)
 
-	outputLines := strings.Split(output, "\n")
+	ctx := withParsedOutput(context.Background())
 
-	for _, outputLine := range outputLines {
-		vagrantOutputLine := vagrantOutputLineFromString(outputLine)
-		if vagrantOutputLine == nil {
-			continue
+	output, err := c.runCommand(ctx, cmdArgs...)
+	return parseCommandOutput(ctx, output, err, false)
+}
+
+// parseCommandOutput parses the machine readable `output` of a command, that failed with `err` unless it's nil.
+// Reading fails for lines longer than `maxOutputLineSize`. The error of the command takes precedence over it.
+//
+// NOTE: The whole output of a command is buffered before it's parsed, so only `ParseMachineReadable` has memory use
+// bounded by the longest line.
+func parseCommandOutput(
+	ctx context.Context,
+	output []byte,
+	err error,
+	skipIgnored bool,
+) ([]*vagrantOutputLine, error) {
+	vagrantOutputLines, scanErr := parseOutputLines(ctx, output)
+	if err == nil && scanErr != nil {
+		err = stacktrace.Propagate(scanErr, "failed to read machine readable output")
+	}
+
+	if !skipIgnored {
+		return vagrantOutputLines, err
+	}
+
+	//noinspection GoPreferNilSlice
+	outputLines := []*vagrantOutputLine{}
+
+	for _, line := range vagrantOutputLines {
+		if !isIgnoredOutputLine(line) {
+			outputLines = append(outputLines, line)
 		}
+	}
 
-		vagrantOutputLines = append(
-			vagrantOutputLines,
-			vagrantOutputLine,
-		)
+	return outputLines, err
+}
+
+// parsedOutput is the output of a single invocation, that's parsed once for metrics, tracing and the caller.
+type parsedOutput struct {
+	output      []byte
+	outputLines []*vagrantOutputLine
+	err         error
+	isParsed    bool
+}
+
+type parsedOutputKey struct{}
+
+// withParsedOutput returns a context of a single invocation, that keeps its output parsed by `parseOutputLines`.
+func withParsedOutput(ctx context.Context) context.Context {
+	return context.WithValue(ctx, parsedOutputKey{}, &parsedOutput{})
+}
+
+// parseOutputLines returns all machine readable lines of `output`. It's parsed once per invocation, when `ctx` is
+// returned by `withParsedOutput`, unless a middleware changes the output.
+func parseOutputLines(ctx context.Context, output []byte) ([]*vagrantOutputLine, error) {
+	parsed, ok := ctx.Value(parsedOutputKey{}).(*parsedOutput)
+	if !ok {
+		return scanOutputLines(bytes.NewReader(output), false)
+	}
+
+	if !parsed.isParsed || !isSameOutput(parsed.output, output) {
+		parsed.outputLines, parsed.err = scanOutputLines(bytes.NewReader(output), false)
+		parsed.output = output
+		parsed.isParsed = true
+	}
+
+	return parsed.outputLines, parsed.err
+}
+
+// isSameOutput returns whether `a` and `b` are the same slice of output, without comparing their contents.
+func isSameOutput(a []byte, b []byte) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// runVagrantCommand executes given vagrant command without `--machine-readable` and returns its raw output.
+func (c *Client) runVagrantCommand(args ...string) ([]byte, error) {
+	return c.runCommand(context.Background(), args...)
+}
+
+// runCommand executes the vagrant binary with given `args` through `Config.Middleware`, metrics, tracing and the audit
+// log. All commands are executed through it.
+func (c *Client) runCommand(ctx context.Context, args ...string) ([]byte, error) {
+	spec := &CommandSpec{
+		Binary: c.Config.BinaryName,
+		Args:   args,
+	}
+
+	if len(c.middleware) == 0 {
+		return c.runCommandSpec(ctx, spec)
+	}
+
+	// NOTE: Commands are executed from within the current working directory, after changing to the one in options
+	workingDirectory, err := c.osExecutor.Getwd()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to get current working directory")
+	}
+
+	spec.WorkingDirectory = workingDirectory
+	spec.Env = c.osExecutor.Environ()
+
+	return chainMiddleware(c.middleware, c.runCommandSpec)(ctx, spec)
+}
+
+// runCommandSpec is the built-in runner at the end of the middleware chain.
+func (c *Client) runCommandSpec(ctx context.Context, spec *CommandSpec) ([]byte, error) {
+	if c.commandRunContextFunc != nil {
+		return c.commandRunContextFunc(ctx, spec)
+	}
+
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
 	}
 
-	return vagrantOutputLines
+	return c.commandRunFunc(spec.Binary, spec.Args...)
 }
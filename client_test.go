@@ -1,9 +1,11 @@
 package vagrant_go
 
 import (
+	"context"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"strings"
 	"testing"
 )
 
@@ -144,6 +146,22 @@ func TestExecuteVagrantCommand(t *testing.T) {
 			assert.True(t, isCommandRunCalled)
 		},
 	)
+
+	t.Run(
+		"when output has a line longer than the maximum, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				return []byte("1546430404,default,provider-name," + strings.Repeat("a", maxOutputLineSize)), nil
+			}
+
+			_, err := client.executeVagrantCommand("version")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "failed to read machine readable output")
+		},
+	)
 }
 
 func TestParseMachineReadableOutput(t *testing.T) {
@@ -152,8 +170,6 @@ func TestParseMachineReadableOutput(t *testing.T) {
 		func(t *testing.T) {
 			t.Parallel()
 
-			client := emptyTestClient(t)
-
 			output := `
 1546430404,default,metadata,provider,libvirt
 1546430404,default,provider-name,libvirt
@@ -163,7 +179,8 @@ func TestParseMachineReadableOutput(t *testing.T) {
 1546430404,,ui,info,Current machine states:\n\ndefault                   running (libvirt)\n\nThe Libvirt domain is running. To stop this machine%!(VAGRANT_COMMA) you can run\n'vagrant halt''. To destroy the machine%!(VAGRANT_COMMA) you can run 'vagrant destroy'.
 `
 
-			lines := client.parseMachineReadableOutput(output)
+			lines, err := scanOutputLines(strings.NewReader(output), true)
+			require.NoError(t, err)
 			require.NotNil(t, lines)
 			require.Len(t, lines, 4)
 
@@ -205,9 +222,9 @@ func TestParseMachineReadableOutput(t *testing.T) {
 		"with blank output, it returns empty slice of lines",
 		func(t *testing.T) {
 			t.Parallel()
-			client := emptyTestClient(t)
 
-			lines := client.parseMachineReadableOutput("")
+			lines, err := scanOutputLines(strings.NewReader(""), true)
+			require.NoError(t, err)
 			require.NotNil(t, lines)
 			assert.Empty(t, lines)
 		},
@@ -217,7 +234,6 @@ func TestParseMachineReadableOutput(t *testing.T) {
 		"with non-machine readable output, that is not blank, it returns empty slice of lines",
 		func(t *testing.T) {
 			t.Parallel()
-			client := emptyTestClient(t)
 
 			// NOTE: Can you think of a more ridiculous output? :)
 			output := `
@@ -235,7 +251,8 @@ tcp        0      0 127.0.0.1:38183         0.0.0.0:*               LISTEN
 tcp        0      0 192.168.13.37:43778     192.121.140.177:80      ESTABLISHED 7376/spotify              
 `
 
-			lines := client.parseMachineReadableOutput(output)
+			lines, err := scanOutputLines(strings.NewReader(output), true)
+			require.NoError(t, err)
 			require.NotNil(t, lines)
 			assert.Empty(t, lines)
 		},
@@ -245,11 +262,131 @@ tcp        0      0 192.168.13.37:43778     192.121.140.177:80      ESTABLISHED
 		"with blank output, it returns empty slice of lines",
 		func(t *testing.T) {
 			t.Parallel()
-			client := emptyTestClient(t)
 
-			lines := client.parseMachineReadableOutput("")
+			lines, err := scanOutputLines(strings.NewReader(""), true)
+			require.NoError(t, err)
 			require.NotNil(t, lines)
 			assert.Empty(t, lines)
 		},
 	)
 }
+
+func TestParseOutputLines(t *testing.T) {
+	t.Run(
+		"with context of an invocation, it parses the same output once",
+		func(t *testing.T) {
+			t.Parallel()
+
+			ctx := withParsedOutput(context.Background())
+			output := []byte("1546430404,default,state,running")
+
+			lines, err := parseOutputLines(ctx, output)
+			require.NoError(t, err)
+			require.Len(t, lines, 1)
+
+			sameLines, err := parseOutputLines(ctx, output)
+			require.NoError(t, err)
+			require.Len(t, sameLines, 1)
+			assert.True(t, lines[0] == sameLines[0])
+
+			changedLines, err := parseOutputLines(ctx, []byte("1546430404,default,state,not_created"))
+			require.NoError(t, err)
+			require.Len(t, changedLines, 1)
+			assert.Equal(t, "not_created", changedLines[0].data[0])
+		},
+	)
+
+	t.Run(
+		"with context of no invocation, it parses output on every call",
+		func(t *testing.T) {
+			t.Parallel()
+
+			output := []byte("1546430404,default,state,running")
+
+			lines, err := parseOutputLines(context.Background(), output)
+			require.NoError(t, err)
+
+			otherLines, err := parseOutputLines(context.Background(), output)
+			require.NoError(t, err)
+			assert.False(t, lines[0] == otherLines[0])
+		},
+	)
+}
+
+func TestExecuteVagrantCommandWithAllLines(t *testing.T) {
+	t.Parallel()
+
+	client := emptyTestClient(t)
+	isCommandRunCalled := false
+
+	client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+		assert.Equal(t, []string{"--machine-readable", "up"}, args)
+		isCommandRunCalled = true
+
+		output := `
+1546430404,default,metadata,provider,libvirt
+1546430404,default,action,up,start
+1546430404,default,ui,info,Bringing machine 'default' up with 'libvirt' provider...
+`
+		return []byte(output), nil
+	}
+
+	outputLines, err := client.executeVagrantCommandWithAllLines("up")
+	require.NoError(t, err)
+	require.Len(t, outputLines, 3)
+
+	assert.Equal(t, "metadata", outputLines[0].kind)
+	assert.Equal(t, []string{"provider", "libvirt"}, outputLines[0].data)
+	assert.Equal(t, "action", outputLines[1].kind)
+	assert.Equal(t, []string{"up", "start"}, outputLines[1].data)
+	assert.Equal(t, "ui", outputLines[2].kind)
+
+	assert.True(t, isCommandRunCalled)
+}
+
+func TestRunCommand(t *testing.T) {
+	t.Run(
+		"with 'commandRunContextFunc' set, it executes command with it and given context",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			type contextKey string
+			ctx := context.WithValue(context.Background(), contextKey("key"), "value")
+
+			client.commandRunContextFunc = func(actualCtx context.Context, spec *CommandSpec) ([]byte, error) {
+				assert.Equal(t, ctx, actualCtx)
+				assert.Equal(t, client.Config.BinaryName, spec.Binary)
+				assert.Equal(t, []string{"status"}, spec.Args)
+				return []byte("output"), nil
+			}
+			client.commandRunFunc = func(cmd string, args ...string) ([]byte, error) {
+				t.Fatal("unexpected call of 'commandRunFunc'")
+				return nil, nil
+			}
+
+			output, err := client.runCommand(ctx, "status")
+			require.NoError(t, err)
+			assert.Equal(t, "output", string(output))
+		},
+	)
+
+	t.Run(
+		"with no 'commandRunContextFunc' set, it executes command with 'commandRunFunc'",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			assert.Nil(t, client.commandRunContextFunc)
+
+			client.commandRunFunc = func(cmd string, args ...string) ([]byte, error) {
+				assert.Equal(t, []string{"status"}, args)
+				return []byte("output"), nil
+			}
+
+			output, err := client.runCommand(context.Background(), "status")
+			require.NoError(t, err)
+			assert.Equal(t, "output", string(output))
+		},
+	)
+}
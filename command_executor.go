@@ -2,15 +2,24 @@ package vagrant_go
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"os"
 	"os/exec"
 )
 
 func realCommandRunFunc(cmd string, args ...string) ([]byte, error) {
+	return realCommandRunContextFunc(context.Background(), &CommandSpec{Binary: cmd, Args: args})
+}
+
+// realCommandRunContextFunc is like `realCommandRunFunc`, but executes `spec` from within its working directory and
+// with its environment, and kills the process when `ctx` is done.
+func realCommandRunContextFunc(ctx context.Context, spec *CommandSpec) ([]byte, error) {
 	var outBuffer bytes.Buffer
 
-	execCmd := exec.Command(cmd, args...)
+	execCmd := exec.CommandContext(ctx, spec.Binary, spec.Args...)
+	execCmd.Dir = spec.WorkingDirectory
+	execCmd.Env = spec.Env
 
 	execCmd.Stdout = io.MultiWriter(os.Stdout, &outBuffer)
 	execCmd.Stderr = io.MultiWriter(os.Stderr, &outBuffer)
@@ -1,14 +1,36 @@
 package vagrant_go
 
+import (
+	"io"
+)
+
 const defaultBinaryName = "vagrant"
 
 type Config struct {
 	// BinaryName is the name of the vagrant executable that's going to be used. It must be present in $PATH.
 	BinaryName string
+	// Middleware wraps every vagrant invocation made through `Client`, in order. The first one is the outermost.
+	Middleware []Middleware
+	// AuditSink, when set, gets an `AuditRecord` appended as a line of JSON for every vagrant invocation made through
+	// `Client`. It's written to after `Middleware`, so records describe commands as executed.
+	AuditSink io.Writer
+	// Policy, when set, is consulted before each operation and may deny it.
+	Policy Policy
+	// Metrics, when set, gets a `CommandMetric` reported for every vagrant invocation made through `Client`.
+	Metrics MetricsCollector
+	// Tracer, when set, gets a span recorded for every vagrant invocation made through `Client`.
+	Tracer Tracer
+	// Retry, when set, retries operations, that are safe to repeat, when they fail with a transient error.
+	Retry *RetryPolicy
+	// Lock, when set, takes advisory file locks before executing commands. See `LockOptions`.
+	Lock *LockOptions
+	// ConcurrencyLimit, when set, limits `Up` and `Reload` operations running at a time on the host.
+	ConcurrencyLimit *ConcurrencyLimitOptions
 }
 
 func DefaultConfig() *Config {
 	return &Config{
 		BinaryName: defaultBinaryName,
+		Middleware: []Middleware{},
 	}
 }
@@ -18,4 +18,5 @@ func TestDefaultConfig(t *testing.T) {
 	config := DefaultConfig()
 	require.NotNil(t, config)
 	assert.Equal(t, defaultBinaryName, config.BinaryName)
+	assert.Empty(t, config.Middleware)
 }
@@ -0,0 +1,156 @@
+package vagrant_go
+
+import (
+	"github.com/palantir/stacktrace"
+)
+
+// EnsureAction is the action taken by `EnsureRunning` or `EnsureDestroyed` for a single machine.
+type EnsureAction string
+
+const (
+	// EnsureActionNone is reported for machines already in the desired state.
+	EnsureActionNone      EnsureAction = "none"
+	EnsureActionResumed   EnsureAction = "resumed"
+	EnsureActionStarted   EnsureAction = "started"
+	EnsureActionCreated   EnsureAction = "created"
+	EnsureActionDestroyed EnsureAction = "destroyed"
+)
+
+// EnsureMachineResult is the result of `EnsureRunning` or `EnsureDestroyed` for a single machine.
+type EnsureMachineResult struct {
+	Name string
+	// PreviousState is the raw state of the machine before any action was taken.
+	PreviousState string
+	Action        EnsureAction
+}
+
+type EnsureResult struct {
+	Machines []*EnsureMachineResult
+}
+
+// EnsureRunning brings targeted machines to a running state, based on their current state. Running machines are
+// left as they are, suspended ones are resumed, other created ones are started without provisioning and missing
+// ones are created with `options`.
+func (api *globalAPI) EnsureRunning(options *UpOptions) (*EnsureResult, error) {
+	statuses, err := api.Status(
+		&StatusOptions{
+			WorkingDirectory: options.WorkingDirectory,
+			Targets:          options.Targets,
+		},
+	)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to query machine states")
+	}
+
+	result := &EnsureResult{
+		Machines: []*EnsureMachineResult{},
+	}
+
+	var toResume, toStart, toCreate []string
+
+	for _, status := range statuses {
+		machineResult := &EnsureMachineResult{
+			Name:          status.Name,
+			PreviousState: status.State,
+		}
+
+		switch status.NormalizedState {
+		case MachineStateRunning:
+			machineResult.Action = EnsureActionNone
+		case MachineStateSuspended:
+			machineResult.Action = EnsureActionResumed
+			toResume = append(toResume, status.Name)
+		case MachineStateNotCreated:
+			machineResult.Action = EnsureActionCreated
+			toCreate = append(toCreate, status.Name)
+		default:
+			machineResult.Action = EnsureActionStarted
+			toStart = append(toStart, status.Name)
+		}
+
+		result.Machines = append(result.Machines, machineResult)
+	}
+
+	if len(toResume) > 0 {
+		err = api.Resume(
+			&ResumeOptions{
+				WorkingDirectory: options.WorkingDirectory,
+				Targets:          toResume,
+			},
+		)
+		if err != nil {
+			return result, stacktrace.Propagate(err, "failed to resume machines %v", toResume)
+		}
+	}
+
+	if len(toStart) > 0 {
+		startOptions := *options
+		startOptions.Targets = toStart
+		// NOTE: Provisioners already ran when the machines were created
+		startOptions.Provision = false
+		startOptions.ProvisionWith = []string{}
+
+		_, err = api.Up(&startOptions)
+		if err != nil {
+			return result, stacktrace.Propagate(err, "failed to start machines %v", toStart)
+		}
+	}
+
+	if len(toCreate) > 0 {
+		createOptions := *options
+		createOptions.Targets = toCreate
+
+		_, err = api.Up(&createOptions)
+		if err != nil {
+			return result, stacktrace.Propagate(err, "failed to create machines %v", toCreate)
+		}
+	}
+
+	return result, nil
+}
+
+// EnsureDestroyed destroys targeted machines, that are created. Missing machines are left as they are.
+func (api *globalAPI) EnsureDestroyed(options *DestroyOptions) (*EnsureResult, error) {
+	statuses, err := api.Status(
+		&StatusOptions{
+			WorkingDirectory: options.WorkingDirectory,
+			Targets:          options.Targets,
+		},
+	)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to query machine states")
+	}
+
+	result := &EnsureResult{
+		Machines: []*EnsureMachineResult{},
+	}
+
+	var toDestroy []string
+
+	for _, status := range statuses {
+		machineResult := &EnsureMachineResult{
+			Name:          status.Name,
+			PreviousState: status.State,
+			Action:        EnsureActionNone,
+		}
+
+		if status.NormalizedState != MachineStateNotCreated {
+			machineResult.Action = EnsureActionDestroyed
+			toDestroy = append(toDestroy, status.Name)
+		}
+
+		result.Machines = append(result.Machines, machineResult)
+	}
+
+	if len(toDestroy) > 0 {
+		destroyOptions := *options
+		destroyOptions.Targets = toDestroy
+
+		_, err = api.Destroy(&destroyOptions)
+		if err != nil {
+			return result, stacktrace.Propagate(err, "failed to destroy machines %v", toDestroy)
+		}
+	}
+
+	return result, nil
+}
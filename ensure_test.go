@@ -0,0 +1,206 @@
+package vagrant_go
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const ensureStatusOutput = `
+1546430404,web,provider-name,virtualbox
+1546430404,web,state,running
+1546430404,db,provider-name,virtualbox
+1546430404,db,state,saved
+1546430404,cache,provider-name,virtualbox
+1546430404,cache,state,poweroff
+1546430404,queue,provider-name,virtualbox
+1546430404,queue,state,not_created
+`
+
+func TestGlobalAPI_EnsureRunning(t *testing.T) {
+	t.Run(
+		"with running, suspended, powered off and missing machines, it resumes, starts and creates only the ones not running",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+			var executedArgs [][]string
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				executedArgs = append(executedArgs, args)
+
+				if args[1] == "status" {
+					return []byte(ensureStatusOutput), nil
+				}
+
+				return []byte{}, nil
+			}
+
+			options := DefaultUpOptions()
+			options.Targets = []string{"/.*/"}
+
+			result, err := client.Global.EnsureRunning(options)
+			require.NoError(t, err)
+
+			require.Len(t, executedArgs, 4)
+			assert.Equal(t, []string{"--machine-readable", "status", "/.*/"}, executedArgs[0])
+			assert.Equal(t, []string{"--machine-readable", "resume", "db"}, executedArgs[1])
+			assert.Equal(t, []string{
+				"--machine-readable",
+				"up",
+				"--no-provision",
+				"--destroy-on-error",
+				"--parallel",
+				"--install-provider",
+				"cache",
+			}, executedArgs[2])
+			assert.Equal(t, []string{
+				"--machine-readable",
+				"up",
+				"--provision",
+				"--destroy-on-error",
+				"--parallel",
+				"--install-provider",
+				"queue",
+			}, executedArgs[3])
+
+			require.Len(t, result.Machines, 4)
+			assert.Equal(t, &EnsureMachineResult{Name: "web", PreviousState: "running", Action: EnsureActionNone}, result.Machines[0])
+			assert.Equal(t, &EnsureMachineResult{Name: "db", PreviousState: "saved", Action: EnsureActionResumed}, result.Machines[1])
+			assert.Equal(t, &EnsureMachineResult{Name: "cache", PreviousState: "poweroff", Action: EnsureActionStarted}, result.Machines[2])
+			assert.Equal(t, &EnsureMachineResult{Name: "queue", PreviousState: "not_created", Action: EnsureActionCreated}, result.Machines[3])
+		},
+	)
+
+	t.Run(
+		"with all machines running, it only queries status",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+			commandRunCalls := 0
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				commandRunCalls++
+				assert.Equal(t, "status", args[1])
+				return []byte("1546430404,web,state,running"), nil
+			}
+
+			result, err := client.Global.EnsureRunning(DefaultUpOptions())
+			require.NoError(t, err)
+
+			assert.Equal(t, 1, commandRunCalls)
+			require.Len(t, result.Machines, 1)
+			assert.Equal(t, EnsureActionNone, result.Machines[0].Action)
+		},
+	)
+
+	t.Run(
+		"with status query returning an error, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				return []byte{}, errors.New("fake error")
+			}
+
+			result, err := client.Global.EnsureRunning(DefaultUpOptions())
+			assert.Nil(t, result)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "failed to query machine states")
+		},
+	)
+
+	t.Run(
+		"with resume returning an error, it returns an error and the planned actions",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				if args[1] == "status" {
+					return []byte(ensureStatusOutput), nil
+				}
+
+				return []byte{}, errors.New("fake error")
+			}
+
+			result, err := client.Global.EnsureRunning(DefaultUpOptions())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "failed to resume machines [db]")
+			require.NotNil(t, result)
+			assert.Len(t, result.Machines, 4)
+		},
+	)
+}
+
+func TestGlobalAPI_EnsureDestroyed(t *testing.T) {
+	t.Run(
+		"with created and missing machines, it destroys only the created ones",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			var executedArgs [][]string
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				executedArgs = append(executedArgs, args)
+
+				if args[1] == "status" {
+					return []byte(ensureStatusOutput), nil
+				}
+
+				return []byte{}, nil
+			}
+
+			result, err := client.Global.EnsureDestroyed(DefaultDestroyOptions())
+			require.NoError(t, err)
+
+			require.Len(t, executedArgs, 2)
+			assert.Equal(t, []string{"--machine-readable", "status"}, executedArgs[0])
+			assert.Equal(t, []string{
+				"--machine-readable",
+				"destroy",
+				"--force",
+				"--parallel",
+				"web",
+				"db",
+				"cache",
+			}, executedArgs[1])
+
+			require.Len(t, result.Machines, 4)
+			assert.Equal(t, EnsureActionDestroyed, result.Machines[0].Action)
+			assert.Equal(t, EnsureActionDestroyed, result.Machines[1].Action)
+			assert.Equal(t, EnsureActionDestroyed, result.Machines[2].Action)
+			assert.Equal(t, &EnsureMachineResult{Name: "queue", PreviousState: "not_created", Action: EnsureActionNone}, result.Machines[3])
+		},
+	)
+
+	t.Run(
+		"with all machines missing, it only queries status",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			commandRunCalls := 0
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				commandRunCalls++
+				return []byte("1546430404,web,state,not_created"), nil
+			}
+
+			result, err := client.Global.EnsureDestroyed(DefaultDestroyOptions())
+			require.NoError(t, err)
+
+			assert.Equal(t, 1, commandRunCalls)
+			require.Len(t, result.Machines, 1)
+			assert.Equal(t, EnsureActionNone, result.Machines[0].Action)
+		},
+	)
+}
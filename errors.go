@@ -0,0 +1,26 @@
+package vagrant_go
+
+import (
+	"github.com/palantir/stacktrace"
+)
+
+// Error codes attached to errors returned by the APIs. Use `stacktrace.GetCode(err)` to check them.
+const (
+	// ErrorCodeVagrantfileExists is returned by `Init` when a Vagrantfile already exists and `Force` is not set.
+	ErrorCodeVagrantfileExists stacktrace.ErrorCode = iota + 1
+	// ErrorCodeUploadNotSupported is returned by `Upload` when the guest of the machine is missing a capability
+	// required by the upload, e.g. creating temporary paths or extracting compressed uploads.
+	ErrorCodeUploadNotSupported
+	// ErrorCodeWaitTimeout is returned by `WaitForState` when the context is done before the desired state is reached.
+	ErrorCodeWaitTimeout
+	// ErrorCodeUnsupportedVersion is returned before executing a command, that's not supported by the installed Vagrant.
+	ErrorCodeUnsupportedVersion
+	// ErrorCodePolicyDenied is returned before executing an operation, that's denied by `Config.Policy`.
+	ErrorCodePolicyDenied
+	// ErrorCodeLockTimeout is returned before executing a command, when `Config.Lock` is set and the lock of the project,
+	// or `VAGRANT_HOME`, is not taken before the timeout.
+	ErrorCodeLockTimeout
+	// ErrorCodeConcurrencyLimitTimeout is returned by `Up` and `Reload`, when `Config.ConcurrencyLimit` is set and no
+	// slot is free before the timeout.
+	ErrorCodeConcurrencyLimitTimeout
+)
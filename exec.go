@@ -0,0 +1,98 @@
+package vagrant_go
+
+import (
+	"context"
+	"github.com/palantir/stacktrace"
+	"strings"
+)
+
+type ExecOptions struct {
+	WorkingDirectory string
+}
+
+func DefaultExecOptions() *ExecOptions {
+	return &ExecOptions{
+		WorkingDirectory: "",
+	}
+}
+
+// Exec executes an arbitrary vagrant subcommand, e.g. `[]string{"cloud", "search", "debian"}`, with
+// `--machine-readable` and returns all of its decoded output lines. The process is killed when `ctx` is done,
+// unless a custom `commandRunFunc` is given to `NewClient`.
+//
+// When the command fails, the returned error contains the error reported by Vagrant and output lines are still
+// returned.
+func (c *Client) Exec(ctx context.Context, args []string, options *ExecOptions) ([]*OutputLine, error) {
+	if len(args) == 0 {
+		return nil, stacktrace.NewError("`args` must not be empty")
+	}
+
+	operation := operationName(args)
+
+	// NOTE: Targets are not known for arbitrary subcommands, so the operation is checked as targeting all machines
+	err := c.checkPolicy(c.osExecutor, operation, options.WorkingDirectory, []string{})
+	if err != nil {
+		return nil, err
+	}
+
+	cmdArgs := append([]string{"--machine-readable"}, args...)
+	ctx = withParsedOutput(ctx)
+
+	var output []byte
+
+	execute := func() error {
+		var err error
+		output, err = c.runCommand(ctx, cmdArgs...)
+		return err
+	}
+
+	// NOTE: Box commands don't belong to a project, but change boxes shared by all projects in `VAGRANT_HOME`
+	if operation == "box" || strings.HasPrefix(operation, "box ") {
+		err = c.withVagrantHomeLock(func() error {
+			return inWorkingDirectory(c.osExecutor, options.WorkingDirectory, execute)
+		})
+	} else {
+		err = c.inProjectDirectory(c.osExecutor, options.WorkingDirectory, execute)
+	}
+
+	vagrantOutputLines, err := parseCommandOutput(ctx, output, err, false)
+
+	outputLines := make([]*OutputLine, 0, len(vagrantOutputLines))
+	for _, line := range vagrantOutputLines {
+		outputLines = append(outputLines, newOutputLine(line))
+	}
+
+	if err != nil {
+		errorClass, message, found := findErrorExit(vagrantOutputLines)
+		if found {
+			return outputLines, stacktrace.Propagate(err, "%s: %s", errorClass, message)
+		}
+
+		return outputLines, stacktrace.Propagate(err, "command execution failed")
+	}
+
+	return outputLines, nil
+}
+
+// NOTE: Subcommands of these commands are part of the operation name, e.g. `box list`.
+var namespacedOperations = []string{"box", "cloud", "plugin", "snapshot"}
+
+// operationName returns the subcommand of `args`, that is the first argument not being a flag, followed by its own
+// subcommand for namespaced commands.
+func operationName(args []string) string {
+	var names []string
+
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		names = append(names, arg)
+
+		if len(names) > 1 || !contains(namespacedOperations, arg) {
+			break
+		}
+	}
+
+	return strings.Join(names, " ")
+}
@@ -0,0 +1,148 @@
+package vagrant_go
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestDefaultExecOptions(t *testing.T) {
+	t.Parallel()
+
+	options := DefaultExecOptions()
+
+	assert.Equal(t, options.WorkingDirectory, "")
+}
+
+func TestClient_Exec(t *testing.T) {
+	t.Run(
+		"with arbitrary subcommand, it executes it with '--machine-readable' in 'WorkingDirectory' and returns all decoded lines",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := projectTestClient(t)
+			isCommandRunCalled := false
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{"--machine-readable", "rdp", "win1"}, args)
+				isCommandRunCalled = true
+
+				output := `
+1546430404,win1,metadata,provider,virtualbox
+1546430405,win1,ui,info,Detecting RDP info...%!(VAGRANT_COMMA) please wait\nAddress: 127.0.0.1:3389
+`
+				return []byte(output), nil
+			}
+
+			options := DefaultExecOptions()
+			options.WorkingDirectory = "/tmp/example"
+
+			outputLines, err := client.Exec(context.Background(), []string{"rdp", "win1"}, options)
+			require.NoError(t, err)
+			assert.True(t, isCommandRunCalled)
+			require.Len(t, outputLines, 2)
+
+			assert.Equal(t, &OutputLine{
+				Timestamp: time.Unix(1546430404, 0),
+				Target:    "win1",
+				Kind:      "metadata",
+				Data:      []string{"provider", "virtualbox"},
+			}, outputLines[0])
+
+			assert.Equal(t, &OutputLine{
+				Timestamp: time.Unix(1546430405, 0),
+				Target:    "win1",
+				Kind:      "ui",
+				Data:      []string{"info", "Detecting RDP info..., please wait\nAddress: 127.0.0.1:3389"},
+			}, outputLines[1])
+
+			client.osExecutor.(*fakeOsExecutor).AssertCalled(t, "Chdir", "/tmp/example")
+			client.osExecutor.(*fakeOsExecutor).AssertCalled(t, "Chdir", "/tmp/anotherexample")
+		},
+	)
+
+	t.Run(
+		"with command failing with `error-exit`, it returns output lines and an error with Vagrant's error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				output := `1546430404,,error-exit,Vagrant::Errors::CLIInvalidUsage,This command requires a plugin.`
+				return []byte(output), errors.New("exit status 1")
+			}
+
+			outputLines, err := client.Exec(context.Background(), []string{"cloud"}, DefaultExecOptions())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "Vagrant::Errors::CLIInvalidUsage: This command requires a plugin.")
+			assert.Len(t, outputLines, 1)
+		},
+	)
+
+	t.Run(
+		"with context done before execution, it does not execute command and returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			isCommandRunCalled := false
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				isCommandRunCalled = true
+				return []byte{}, nil
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := client.Exec(ctx, []string{"status"}, DefaultExecOptions())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), context.Canceled.Error())
+			assert.False(t, isCommandRunCalled)
+		},
+	)
+
+	t.Run(
+		"with empty args, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+
+			_, err := client.Exec(context.Background(), []string{}, DefaultExecOptions())
+			assert.Error(t, err)
+		},
+	)
+}
+
+func TestOperationName(t *testing.T) {
+	t.Run(
+		"with flags before the subcommand, it returns the subcommand",
+		func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, "destroy", operationName([]string{"--machine-readable", "destroy", "-f", "web"}))
+		},
+	)
+
+	t.Run(
+		"with namespaced subcommand, it returns both subcommands",
+		func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, "box list", operationName([]string{"--machine-readable", "box", "list"}))
+		},
+	)
+
+	t.Run(
+		"with no subcommand, it returns empty string",
+		func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, "", operationName([]string{"--version"}))
+		},
+	)
+}
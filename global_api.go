@@ -4,16 +4,50 @@ import (
 	"fmt"
 	"github.com/kevinburke/ssh_config"
 	"github.com/palantir/stacktrace"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Compile-time proof of interface implementation.
 var _ GlobalAPI = (*globalAPI)(nil)
 
 type GlobalAPI interface {
-	Up(options *UpOptions) error
-	Destroy(options *DestroyOptions) error
+	Up(options *UpOptions) (*UpResult, error)
+	Destroy(options *DestroyOptions) (*DestroyResult, error)
 	SshConfig(options *SshConfigOptions) (*ssh_config.Config, error)
+	// SshConnectionInfo is a typed alternative to `SshConfig`, keyed by machine name.
+	SshConnectionInfo(options *SshConfigOptions) (map[string]*SshConnectionInfo, error)
+	Validate(options *ValidateOptions) ([]*ValidationError, error)
+	Init(options *InitOptions) error
+	Upload(machine string, source string, destination string, options *UploadOptions) (string, error)
+	// WinrmConfig returns the WinRM connection info keyed by machine name.
+	WinrmConfig(options *WinrmConfigOptions) (map[string]*WinrmConfig, error)
+	Status(options *StatusOptions) ([]*MachineStatus, error)
+	Halt(options *HaltOptions) error
+	Resume(options *ResumeOptions) error
+	Reload(options *ReloadOptions) (*ReloadResult, error)
+	Provision(options *ProvisionOptions) (*ProvisionResult, error)
+	EnsureRunning(options *UpOptions) (*EnsureResult, error)
+	EnsureDestroyed(options *DestroyOptions) (*EnsureResult, error)
+	// SshExec executes `command` on the guest of `machine` and returns its combined output.
+	SshExec(machine string, command string, options *SshExecOptions) (string, error)
+	// PlanUp returns the command `Up` would execute with `options`, without executing anything.
+	PlanUp(options *UpOptions) (*CommandSpec, error)
+	PlanDestroy(options *DestroyOptions) (*CommandSpec, error)
+	PlanStatus(options *StatusOptions) (*CommandSpec, error)
+	PlanHalt(options *HaltOptions) (*CommandSpec, error)
+	PlanResume(options *ResumeOptions) (*CommandSpec, error)
+	PlanReload(options *ReloadOptions) (*CommandSpec, error)
+	PlanProvision(options *ProvisionOptions) (*CommandSpec, error)
+	// PlanSshConfig returns the command both `SshConfig` and `SshConnectionInfo` would execute with `options`.
+	PlanSshConfig(options *SshConfigOptions) (*CommandSpec, error)
+	PlanValidate(options *ValidateOptions) (*CommandSpec, error)
+	PlanInit(options *InitOptions) (*CommandSpec, error)
+	PlanUpload(machine string, source string, destination string, options *UploadOptions) (*CommandSpec, error)
+	PlanWinrmConfig(options *WinrmConfigOptions) (*CommandSpec, error)
 }
 
 type globalAPI struct {
@@ -29,6 +63,11 @@ type UpOptions struct {
 	Parallel         bool
 	Provider         string
 	InstallProvider  bool
+	// Retry opts in to retrying with `Config.Retry`. Retrying may repeat provisioning of machines, that were created
+	// before the failure.
+	Retry bool
+	// Targets are machine names, or regular expressions in format of `/web\d/`. All machines are targeted if empty.
+	Targets []string
 }
 
 func DefaultUpOptions() *UpOptions {
@@ -40,13 +79,26 @@ func DefaultUpOptions() *UpOptions {
 		Parallel:         true,
 		Provider:         "",
 		InstallProvider:  true,
+		Retry:            false,
+		Targets:          []string{},
 	}
 }
 
+// UpResult is the result of `Up` for each targeted machine.
+type UpResult struct {
+	Machines []*MachineResult
+	Timeline *Timeline
+	// QueueWait is the time spent waiting for a slot of `Config.ConcurrencyLimit`. The result is returned with it also
+	// when waiting times out.
+	QueueWait time.Duration
+}
+
 type DestroyOptions struct {
 	WorkingDirectory string
 	Force            bool
 	Parallel         bool
+	// Targets are machine names, or regular expressions in format of `/web\d/`. All machines are targeted if empty.
+	Targets []string
 }
 
 func DefaultDestroyOptions() *DestroyOptions {
@@ -54,9 +106,15 @@ func DefaultDestroyOptions() *DestroyOptions {
 		WorkingDirectory: "",
 		Force:            true,
 		Parallel:         true,
+		Targets:          []string{},
 	}
 }
 
+// DestroyResult is the result of `Destroy` for each targeted machine.
+type DestroyResult struct {
+	Machines []*MachineResult
+}
+
 type SshConfigOptions struct {
 	WorkingDirectory string
 	Name             string
@@ -69,7 +127,264 @@ func DefaultSshConfigOptions() *SshConfigOptions {
 	}
 }
 
-func (api *globalAPI) Up(options *UpOptions) error {
+// SshConnectionInfo is the SSH connection info of a single machine, as returned by `vagrant ssh-config`.
+type SshConnectionInfo struct {
+	Machine string
+	// Host is the alias of the host in the ssh_config.
+	Host                  string
+	HostName              string
+	Port                  int
+	User                  string
+	IdentityFiles         []string
+	StrictHostKeyChecking string
+	ForwardAgent          bool
+	ProxyCommand          string
+}
+
+type ValidateOptions struct {
+	WorkingDirectory string
+	Name             string
+	IgnoreProvider   bool
+}
+
+func DefaultValidateOptions() *ValidateOptions {
+	return &ValidateOptions{
+		WorkingDirectory: "",
+		Name:             "",
+		IgnoreProvider:   true,
+	}
+}
+
+// ValidationError is a single error reported by `vagrant validate` for a section (e.g. `vm`, `ssh`) of the Vagrantfile.
+type ValidationError struct {
+	// Machine is empty when Vagrant does not attribute the error to a machine and no `Name` is given in options.
+	Machine string
+	Section string
+	Message string
+}
+
+type InitOptions struct {
+	WorkingDirectory string
+	BoxName          string
+	BoxURL           string
+	BoxVersion       string
+	// Force overwrites an existing Vagrantfile.
+	Force   bool
+	Minimal bool
+	// Output is the path of the created Vagrantfile, relative to `WorkingDirectory`. Use `-` for stdout.
+	Output   string
+	Template string
+}
+
+func DefaultInitOptions() *InitOptions {
+	return &InitOptions{
+		WorkingDirectory: "",
+		BoxName:          "",
+		BoxURL:           "",
+		BoxVersion:       "",
+		Force:            false,
+		Minimal:          false,
+		Output:           "",
+		Template:         "",
+	}
+}
+
+type UploadOptions struct {
+	WorkingDirectory string
+	Compress         bool
+	// CompressionType is either `tgz` or `zip`. Setting it implies `Compress`.
+	CompressionType string
+	// Temporary uploads to a temporary path on the guest. The given destination is ignored.
+	Temporary bool
+}
+
+func DefaultUploadOptions() *UploadOptions {
+	return &UploadOptions{
+		WorkingDirectory: "",
+		Compress:         false,
+		CompressionType:  "",
+		Temporary:        false,
+	}
+}
+
+type WinrmConfigOptions struct {
+	WorkingDirectory string
+	// Host overrides the name of the host in the config. By default it's the machine name.
+	Host string
+}
+
+func DefaultWinrmConfigOptions() *WinrmConfigOptions {
+	return &WinrmConfigOptions{
+		WorkingDirectory: "",
+		Host:             "",
+	}
+}
+
+// WinrmConfig is the WinRM connection info of a single machine, as returned by `vagrant winrm-config`.
+type WinrmConfig struct {
+	Host     string
+	HostName string
+	Port     int
+	Username string
+	Password string
+	// Transport is empty, when it's not reported. Vagrant uses `negotiate` then.
+	Transport   string
+	RDPHostName string
+	RDPPort     int
+	RDPUsername string
+	RDPPassword string
+}
+
+type StatusOptions struct {
+	WorkingDirectory string
+	// Targets are machine names, or regular expressions in format of `/web\d/`. All machines are targeted if empty.
+	Targets []string
+}
+
+func DefaultStatusOptions() *StatusOptions {
+	return &StatusOptions{
+		WorkingDirectory: "",
+		Targets:          []string{},
+	}
+}
+
+// MachineStatus is the status of a single machine, as returned by `vagrant status`.
+type MachineStatus struct {
+	Name     string
+	Provider string
+	// State is the raw state reported by the provider, e.g. `poweroff` by VirtualBox or `shutoff` by libvirt.
+	State string
+	// NormalizedState is `State` normalized across providers.
+	NormalizedState MachineState
+	StateHumanShort string
+	StateHumanLong  string
+}
+
+type HaltOptions struct {
+	WorkingDirectory string
+	Force            bool
+	// Targets are machine names, or regular expressions in format of `/web\d/`. All machines are targeted if empty.
+	Targets []string
+}
+
+func DefaultHaltOptions() *HaltOptions {
+	return &HaltOptions{
+		WorkingDirectory: "",
+		Force:            false,
+		Targets:          []string{},
+	}
+}
+
+type ResumeOptions struct {
+	WorkingDirectory string
+	// Targets are machine names, or regular expressions in format of `/web\d/`. All machines are targeted if empty.
+	Targets []string
+}
+
+func DefaultResumeOptions() *ResumeOptions {
+	return &ResumeOptions{
+		WorkingDirectory: "",
+		Targets:          []string{},
+	}
+}
+
+type ReloadOptions struct {
+	WorkingDirectory string
+	Provision        bool
+	ProvisionWith    []string
+	// Targets are machine names, or regular expressions in format of `/web\d/`. All machines are targeted if empty.
+	Targets []string
+}
+
+func DefaultReloadOptions() *ReloadOptions {
+	return &ReloadOptions{
+		WorkingDirectory: "",
+		Provision:        false,
+		ProvisionWith:    []string{},
+		Targets:          []string{},
+	}
+}
+
+// ReloadResult is the result of `Reload` for each targeted machine.
+type ReloadResult struct {
+	Machines []*MachineResult
+	Timeline *Timeline
+	// QueueWait is the time spent waiting for a slot of `Config.ConcurrencyLimit`. The result is returned with it also
+	// when waiting times out.
+	QueueWait time.Duration
+}
+
+type ProvisionOptions struct {
+	WorkingDirectory string
+	ProvisionWith    []string
+	// Targets are machine names, or regular expressions in format of `/web\d/`. All machines are targeted if empty.
+	Targets []string
+}
+
+func DefaultProvisionOptions() *ProvisionOptions {
+	return &ProvisionOptions{
+		WorkingDirectory: "",
+		ProvisionWith:    []string{},
+		Targets:          []string{},
+	}
+}
+
+// ProvisionResult is the result of `Provision` for each targeted machine.
+type ProvisionResult struct {
+	Machines []*MachineResult
+	Timeline *Timeline
+}
+
+type SshExecOptions struct {
+	WorkingDirectory string
+}
+
+func DefaultSshExecOptions() *SshExecOptions {
+	return &SshExecOptions{
+		WorkingDirectory: "",
+	}
+}
+
+func (api *globalAPI) Up(options *UpOptions) (*UpResult, error) {
+	err := api.checkPolicy("up", options.WorkingDirectory, options.Targets...)
+	if err != nil {
+		return nil, err
+	}
+
+	var queueWait time.Duration
+
+	// NOTE: A slot of the concurrency limit is taken per attempt, so that it's not held during backoff
+	args := upArgs(options, api.client.supports(capabilityInstallProvider))
+
+	execute := func() ([]*vagrantOutputLine, error) {
+		outputLines, wait, err := api.executeWithConcurrencyLimit(options.WorkingDirectory, args...)
+		queueWait += wait
+
+		return outputLines, err
+	}
+
+	var outputLines []*vagrantOutputLine
+
+	if options.Retry {
+		outputLines, err = api.client.withRetry(execute)
+	} else {
+		outputLines, err = execute()
+	}
+
+	if outputLines == nil && !isConcurrencyLimitTimeout(err) {
+		return nil, err
+	}
+
+	result := &UpResult{
+		Machines:  machineResultsFromOutputLines(outputLines, "up", "running"),
+		Timeline:  timelineFromOutputLines(outputLines, "up"),
+		QueueWait: queueWait,
+	}
+
+	return result, err
+}
+
+func upArgs(options *UpOptions, supportsInstallProvider bool) []string {
 	args := []string{
 		"up",
 	}
@@ -100,37 +415,37 @@ func (api *globalAPI) Up(options *UpOptions) error {
 		args = append(args, "--provider", options.Provider)
 	}
 
-	if options.InstallProvider {
-		args = append(args, "--install-provider")
-	} else {
-		args = append(args, "--no-install-provider")
+	// NOTE: Older releases always install providers
+	if supportsInstallProvider {
+		if options.InstallProvider {
+			args = append(args, "--install-provider")
+		} else {
+			args = append(args, "--no-install-provider")
+		}
 	}
 
-	if len(options.WorkingDirectory) > 0 {
-		oldWorkingDir, err := api.osExecutor.Getwd()
-		if err != nil {
-			return err
-		}
+	return append(args, options.Targets...)
+}
 
-		err = api.osExecutor.Chdir(options.WorkingDirectory)
-		if err != nil {
-			return err
-		}
+func (api *globalAPI) Destroy(options *DestroyOptions) (*DestroyResult, error) {
+	err := api.checkPolicy("destroy", options.WorkingDirectory, options.Targets...)
+	if err != nil {
+		return nil, err
+	}
 
-		_, err = api.client.executeVagrantCommand(args...)
-		if err != nil {
-			return err
-		}
+	outputLines, err := api.executeWithAllLinesInWorkingDirectory(options.WorkingDirectory, destroyArgs(options)...)
+	if outputLines == nil {
+		return nil, err
+	}
 
-		err = api.osExecutor.Chdir(oldWorkingDir)
-		return err
+	result := &DestroyResult{
+		Machines: machineResultsFromOutputLines(outputLines, "destroy", "not_created"),
 	}
 
-	_, err := api.client.executeVagrantCommand(args...)
-	return err
+	return result, err
 }
 
-func (api *globalAPI) Destroy(options *DestroyOptions) error {
+func destroyArgs(options *DestroyOptions) []string {
 	args := []string{
 		"destroy",
 	}
@@ -145,31 +460,103 @@ func (api *globalAPI) Destroy(options *DestroyOptions) error {
 		args = append(args, "--no-parallel")
 	}
 
-	if len(options.WorkingDirectory) > 0 {
-		oldWorkingDir, err := api.osExecutor.Getwd()
+	return append(args, options.Targets...)
+}
+
+func (api *globalAPI) SshConfig(options *SshConfigOptions) (*ssh_config.Config, error) {
+	return api.sshConfig(options)
+}
+
+func (api *globalAPI) sshConfig(options *SshConfigOptions, targets ...string) (*ssh_config.Config, error) {
+	hostConfigs, err := api.sshHostConfigs(options, targets...)
+	if err != nil {
+		return nil, err
+	}
+
+	var output string
+
+	for _, hostConfig := range hostConfigs {
+		output += fmt.Sprintf("%s\n", hostConfig.config)
+	}
+
+	sshConfig, err := ssh_config.Decode(
+		strings.NewReader(output),
+	)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to decode ssh_config")
+	}
+
+	return sshConfig, nil
+}
+
+func (api *globalAPI) SshConnectionInfo(options *SshConfigOptions) (map[string]*SshConnectionInfo, error) {
+	hostConfigs, err := api.sshHostConfigs(options)
+	if err != nil {
+		return nil, err
+	}
+
+	connectionInfos := map[string]*SshConnectionInfo{}
+
+	for _, hostConfig := range hostConfigs {
+		sshConfig, err := ssh_config.Decode(
+			strings.NewReader(hostConfig.config),
+		)
 		if err != nil {
-			return err
+			return nil, stacktrace.Propagate(err, "failed to decode ssh_config of machine `%s`", hostConfig.machine)
 		}
 
-		err = api.osExecutor.Chdir(options.WorkingDirectory)
+		connectionInfo, err := sshConnectionInfoFromConfig(hostConfig.machine, sshConfig)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		_, err = api.client.executeVagrantCommand(args...)
-		if err != nil {
-			return err
+		connectionInfos[connectionInfo.Machine] = connectionInfo
+	}
+
+	return connectionInfos, nil
+}
+
+type sshHostConfig struct {
+	machine string
+	config  string
+}
+
+func (api *globalAPI) sshHostConfigs(options *SshConfigOptions, targets ...string) ([]*sshHostConfig, error) {
+	err := api.checkPolicy("ssh-config", options.WorkingDirectory, targets...)
+	if err != nil {
+		return nil, err
+	}
+
+	args := sshConfigArgs(options, targets...)
+
+	outputLines, err := api.client.withRetry(func() ([]*vagrantOutputLine, error) {
+		return api.executeInWorkingDirectory(options.WorkingDirectory, args...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	//noinspection GoPreferNilSlice
+	hostConfigs := []*sshHostConfig{}
+
+	for _, line := range outputLines {
+		if len(line.data) < 1 || line.kind != "ssh-config" {
+			continue
 		}
 
-		err = api.osExecutor.Chdir(oldWorkingDir)
-		return err
+		hostConfigs = append(
+			hostConfigs,
+			&sshHostConfig{
+				machine: line.target,
+				config:  unescapeMachineReadable(line.data[0]),
+			},
+		)
 	}
 
-	_, err := api.client.executeVagrantCommand(args...)
-	return err
+	return hostConfigs, nil
 }
 
-func (api *globalAPI) SshConfig(options *SshConfigOptions) (*ssh_config.Config, error) {
+func sshConfigArgs(options *SshConfigOptions, targets ...string) []string {
 	args := []string{
 		"ssh-config",
 	}
@@ -178,52 +565,806 @@ func (api *globalAPI) SshConfig(options *SshConfigOptions) (*ssh_config.Config,
 		args = append(args, "--name", options.Name)
 	}
 
-	var oldWorkingDir string
-	var err error
+	return append(args, targets...)
+}
+
+const defaultSshPort = 22
 
-	hasWorkingDir := len(options.WorkingDirectory) > 0
-	if hasWorkingDir {
-		oldWorkingDir, err = api.osExecutor.Getwd()
-		if err != nil {
-			return nil, err
+func sshConnectionInfoFromConfig(machine string, sshConfig *ssh_config.Config) (*SshConnectionInfo, error) {
+	connectionInfo := &SshConnectionInfo{
+		Machine:       machine,
+		Port:          defaultSshPort,
+		IdentityFiles: []string{},
+	}
+
+	for _, host := range sshConfig.Hosts {
+		for _, node := range host.Nodes {
+			kv, ok := node.(*ssh_config.KV)
+			if !ok {
+				continue
+			}
+
+			var err error
+
+			switch strings.ToLower(kv.Key) {
+			case "hostname":
+				connectionInfo.HostName = kv.Value
+			case "port":
+				connectionInfo.Port, err = strconv.Atoi(kv.Value)
+			case "user":
+				connectionInfo.User = kv.Value
+			case "identityfile":
+				connectionInfo.IdentityFiles = append(connectionInfo.IdentityFiles, kv.Value)
+			case "stricthostkeychecking":
+				connectionInfo.StrictHostKeyChecking = kv.Value
+			case "forwardagent":
+				connectionInfo.ForwardAgent = strings.ToLower(kv.Value) == "yes"
+			case "proxycommand":
+				connectionInfo.ProxyCommand = kv.Value
+			}
+
+			if err != nil {
+				return nil, stacktrace.Propagate(err, "invalid `%s` of machine `%s`", kv.Key, machine)
+			}
 		}
 
-		err = api.osExecutor.Chdir(options.WorkingDirectory)
-		if err != nil {
-			return nil, err
+		if len(connectionInfo.Host) == 0 && len(host.Patterns) > 0 && host.Patterns[0].String() != "*" {
+			connectionInfo.Host = host.Patterns[0].String()
 		}
 	}
 
-	outputLines, err := api.client.executeVagrantCommand(args...)
+	// NOTE: Fallback to the host alias, in case Vagrant did not report the machine name
+	if len(connectionInfo.Machine) == 0 {
+		connectionInfo.Machine = connectionInfo.Host
+	}
+
+	return connectionInfo, nil
+}
+
+func (api *globalAPI) Validate(options *ValidateOptions) ([]*ValidationError, error) {
+	err := api.checkPolicy("validate", options.WorkingDirectory, options.Name)
 	if err != nil {
 		return nil, err
 	}
 
-	var sshConfig *ssh_config.Config
-	var output string
+	outputLines, err := api.executeInWorkingDirectory(options.WorkingDirectory, validateArgs(options)...)
+
+	// NOTE: Use 0 element slice in case there's nothing to return
+	//noinspection GoPreferNilSlice
+	validationErrors := []*ValidationError{}
 
 	for _, line := range outputLines {
-		if len(line.data) < 1 || line.kind != "ssh-config" {
+		if line.kind != "error-exit" || len(line.data) < 2 || line.data[0] != configInvalidErrorClass {
 			continue
 		}
 
-		hostConfig := strings.Replace(line.data[0], `\n`, "\n", -1)
-		output += fmt.Sprintf("%s\n", hostConfig)
+		machine := line.target
+		if len(machine) == 0 {
+			machine = options.Name
+		}
+
+		validationErrors = append(
+			validationErrors,
+			parseValidationErrors(machine, unescapeMachineReadable(line.data[1]))...,
+		)
+	}
+
+	if len(validationErrors) > 0 {
+		return validationErrors, nil
 	}
 
-	sshConfig, err = ssh_config.Decode(
-		strings.NewReader(output),
-	)
 	if err != nil {
-		return nil, stacktrace.Propagate(err, "failed to decode ssh_config")
+		return nil, stacktrace.Propagate(err, "command execution failed")
 	}
 
-	if hasWorkingDir {
-		err = api.osExecutor.Chdir(oldWorkingDir)
-		if err != nil {
-			return nil, err
+	return validationErrors, nil
+}
+
+func validateArgs(options *ValidateOptions) []string {
+	args := []string{
+		"validate",
+	}
+
+	if options.IgnoreProvider {
+		args = append(args, "--ignore-provider")
+	}
+
+	if len(options.Name) > 0 {
+		args = append(args, options.Name)
+	}
+
+	return args
+}
+
+const configInvalidErrorClass = "Vagrant::Errors::ConfigInvalid"
+
+// NOTE: The message of `Vagrant::Errors::ConfigInvalid` lists errors grouped by section in format of:
+//
+//	vm:
+//	* A box must be specified.
+func parseValidationErrors(machine string, message string) []*ValidationError {
+	//noinspection GoPreferNilSlice
+	validationErrors := []*ValidationError{}
+
+	var section string
+
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(line, "* "):
+			validationErrors = append(
+				validationErrors,
+				&ValidationError{
+					Machine: machine,
+					Section: section,
+					Message: strings.TrimPrefix(line, "* "),
+				},
+			)
+		case strings.HasSuffix(line, ":") && !strings.ContainsAny(line, " \t"):
+			section = strings.TrimSuffix(line, ":")
 		}
 	}
 
-	return sshConfig, nil
+	return validationErrors
+}
+
+// executeInWorkingDirectory executes given vagrant command from within `workingDirectory`, if not empty,
+// and changes back to the current working directory afterwards.
+func (api *globalAPI) executeInWorkingDirectory(workingDirectory string, args ...string) ([]*vagrantOutputLine, error) {
+	var outputLines []*vagrantOutputLine
+
+	err := api.inWorkingDirectory(workingDirectory, func() error {
+		var err error
+		outputLines, err = api.client.executeVagrantCommand(args...)
+		return err
+	})
+
+	return outputLines, err
+}
+
+// executeWithAllLinesInWorkingDirectory is like `executeInWorkingDirectory`, but keeps the `metadata`, `ui` and
+// `action` lines.
+func (api *globalAPI) executeWithAllLinesInWorkingDirectory(
+	workingDirectory string,
+	args ...string,
+) ([]*vagrantOutputLine, error) {
+	var outputLines []*vagrantOutputLine
+
+	err := api.inWorkingDirectory(workingDirectory, func() error {
+		var err error
+		outputLines, err = api.client.executeVagrantCommandWithAllLines(args...)
+		return err
+	})
+
+	return outputLines, err
+}
+
+// executeWithConcurrencyLimit is like `executeWithAllLinesInWorkingDirectory`, but holds a slot of
+// `Config.ConcurrencyLimit`, if set. It returns the time spent waiting for the slot.
+// NOTE: The lock of the project is taken before the slot, so that a slot isn't held waiting for the project.
+func (api *globalAPI) executeWithConcurrencyLimit(
+	workingDirectory string,
+	args ...string,
+) ([]*vagrantOutputLine, time.Duration, error) {
+	var outputLines []*vagrantOutputLine
+	var queueWait time.Duration
+
+	err := api.inWorkingDirectory(workingDirectory, func() error {
+		var err error
+
+		queueWait, err = api.client.withConcurrencyLimit(func() error {
+			var err error
+			outputLines, err = api.client.executeVagrantCommandWithAllLines(args...)
+			return err
+		})
+
+		return err
+	})
+
+	return outputLines, queueWait, err
+}
+
+// isConcurrencyLimitTimeout returns whether `err` is a timeout waiting for a slot of `Config.ConcurrencyLimit`. Results
+// are still returned with it, so that the time spent waiting is known.
+func isConcurrencyLimitTimeout(err error) bool {
+	return err != nil && stacktrace.GetCode(err) == ErrorCodeConcurrencyLimitTimeout
+}
+
+// inWorkingDirectory calls `fn` from within `workingDirectory`, holding the lock of the project if `Config.Lock` is set.
+func (api *globalAPI) inWorkingDirectory(workingDirectory string, fn func() error) error {
+	return api.client.inProjectDirectory(api.osExecutor, workingDirectory, fn)
+}
+
+var uploadCompressionTypes = []string{"tgz", "zip"}
+
+var uploadNotSupportedErrorClasses = []string{
+	"Vagrant::Errors::UploadMissingTempCapability",
+	"Vagrant::Errors::UploadMissingExtractCapability",
+}
+
+func (api *globalAPI) Upload(
+	machine string,
+	source string,
+	destination string,
+	options *UploadOptions,
+) (string, error) {
+	err := api.checkPolicy("upload", options.WorkingDirectory, machine)
+	if err != nil {
+		return "", err
+	}
+
+	err = api.client.requireCapability(capabilityUpload)
+	if err != nil {
+		return "", err
+	}
+
+	args, err := uploadArgs(machine, source, destination, options)
+	if err != nil {
+		return "", err
+	}
+
+	sourcePath := source
+	if !filepath.IsAbs(sourcePath) && len(options.WorkingDirectory) > 0 {
+		sourcePath = filepath.Join(options.WorkingDirectory, sourcePath)
+	}
+
+	sourceInfo, err := api.osExecutor.Stat(sourcePath)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "failed to stat upload source `%s`", sourcePath)
+	}
+
+	outputLines, err := api.executeWithAllLinesInWorkingDirectory(options.WorkingDirectory, args...)
+	if err != nil {
+		errorClass, message, found := findErrorExit(outputLines)
+		if found && contains(uploadNotSupportedErrorClasses, errorClass) {
+			return "", stacktrace.PropagateWithCode(
+				err,
+				ErrorCodeUploadNotSupported,
+				"guest of machine `%s` does not support the upload: %s",
+				machine,
+				message,
+			)
+		}
+
+		return "", stacktrace.Propagate(err, "command execution failed")
+	}
+
+	sourceType := "file"
+	if sourceInfo.IsDir() {
+		sourceType = "directory"
+	}
+
+	// NOTE: Vagrant reports the final destination, including generated temporary paths, as
+	// `Uploading <type> <source> to <destination>`.
+	uploadingPrefix := fmt.Sprintf("Uploading %s %s to ", sourceType, source)
+
+	for _, line := range outputLines {
+		if line.kind != "ui" || len(line.data) < 2 {
+			continue
+		}
+
+		message := strings.TrimSpace(unescapeMachineReadable(line.data[1]))
+
+		index := strings.Index(message, uploadingPrefix)
+		if index >= 0 {
+			return message[index+len(uploadingPrefix):], nil
+		}
+	}
+
+	// NOTE: The generated temporary path is known only from the output
+	if options.Temporary {
+		return "", stacktrace.NewError("temporary destination of upload to machine `%s` not reported", machine)
+	}
+
+	if len(destination) == 0 {
+		return filepath.Base(source), nil
+	}
+
+	return destination, nil
+}
+
+func uploadArgs(machine string, source string, destination string, options *UploadOptions) ([]string, error) {
+	if len(source) == 0 {
+		return nil, stacktrace.NewError("`source` must be set")
+	}
+
+	args := []string{
+		"upload",
+	}
+
+	if options.Temporary {
+		args = append(args, "--temporary")
+	}
+
+	if options.Compress || len(options.CompressionType) > 0 {
+		args = append(args, "--compress")
+	}
+
+	if len(options.CompressionType) > 0 {
+		if !contains(uploadCompressionTypes, options.CompressionType) {
+			return nil, stacktrace.NewError(
+				"unsupported `CompressionType` `%s`, expected one of %v",
+				options.CompressionType,
+				uploadCompressionTypes,
+			)
+		}
+
+		args = append(args, "--compression-type", options.CompressionType)
+	}
+
+	args = append(args, source)
+
+	if !options.Temporary {
+		// NOTE: `destination` is positional, so it must be given when `machine` is
+		if len(destination) == 0 && len(machine) > 0 {
+			destination = filepath.Base(source)
+		}
+
+		if len(destination) > 0 {
+			args = append(args, destination)
+		}
+	}
+
+	if len(machine) > 0 {
+		args = append(args, machine)
+	}
+
+	return args, nil
+}
+
+func (api *globalAPI) WinrmConfig(options *WinrmConfigOptions) (map[string]*WinrmConfig, error) {
+	err := api.checkPolicy("winrm-config", options.WorkingDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	err = api.client.requireCapability(capabilityWinrmConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	outputLines, err := api.client.withRetry(func() ([]*vagrantOutputLine, error) {
+		return api.executeWithAllLinesInWorkingDirectory(options.WorkingDirectory, winrmConfigArgs(options)...)
+	})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "command execution failed")
+	}
+
+	var hostConfigs []*winrmHostConfig
+
+	for _, line := range outputLines {
+		if len(line.data) < 1 || line.kind != "winrm-config" {
+			continue
+		}
+
+		hostConfigs = append(
+			hostConfigs,
+			&winrmHostConfig{
+				machine: line.target,
+				config:  unescapeMachineReadable(line.data[0]),
+			},
+		)
+	}
+
+	// NOTE: Older Vagrant releases print the config only as `ui` lines
+	if len(hostConfigs) == 0 {
+		for _, line := range outputLines {
+			if len(line.data) < 2 || line.kind != "ui" {
+				continue
+			}
+
+			message := unescapeMachineReadable(line.data[1])
+			if strings.HasPrefix(strings.TrimSpace(message), "Host ") {
+				hostConfigs = append(
+					hostConfigs,
+					&winrmHostConfig{
+						machine: line.target,
+						config:  message,
+					},
+				)
+			}
+		}
+	}
+
+	winrmConfigs := map[string]*WinrmConfig{}
+
+	for _, hostConfig := range hostConfigs {
+		configs, err := parseWinrmConfigs(hostConfig.config)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "failed to decode winrm-config")
+		}
+
+		for _, config := range configs {
+			// NOTE: `ui` lines of older Vagrant releases don't have a target, so the host is the only name there is
+			machine := hostConfig.machine
+			if len(machine) == 0 {
+				machine = config.Host
+			}
+
+			winrmConfigs[machine] = config
+		}
+	}
+
+	return winrmConfigs, nil
+}
+
+type winrmHostConfig struct {
+	machine string
+	config  string
+}
+
+func winrmConfigArgs(options *WinrmConfigOptions) []string {
+	args := []string{
+		"winrm-config",
+	}
+
+	if len(options.Host) > 0 {
+		args = append(args, "--host", options.Host)
+	}
+
+	return args
+}
+
+// NOTE: The output of `vagrant winrm-config` is in ssh_config like format of:
+//
+//	Host default
+//	  HostName 127.0.0.1
+//	  User vagrant
+//	  Password vagrant
+//	  Port 55985
+func parseWinrmConfigs(output string) ([]*WinrmConfig, error) {
+	var configs []*WinrmConfig
+	var config *WinrmConfig
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		key := fields[0]
+		value := strings.Join(fields[1:], " ")
+
+		if key == "Host" {
+			config = &WinrmConfig{
+				Host: value,
+			}
+			configs = append(configs, config)
+
+			continue
+		}
+
+		if config == nil {
+			return nil, stacktrace.NewError("`%s` found before `Host`", key)
+		}
+
+		var err error
+
+		switch key {
+		case "HostName":
+			config.HostName = value
+		case "Port":
+			config.Port, err = strconv.Atoi(value)
+		case "User":
+			config.Username = value
+		case "Password":
+			config.Password = value
+		case "Transport":
+			config.Transport = value
+		case "RDPHostName":
+			config.RDPHostName = value
+		case "RDPPort":
+			config.RDPPort, err = strconv.Atoi(value)
+		case "RDPUser":
+			config.RDPUsername = value
+		case "RDPPassword":
+			config.RDPPassword = value
+		}
+
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "invalid `%s` of host `%s`", key, config.Host)
+		}
+	}
+
+	return configs, nil
+}
+
+func (api *globalAPI) Status(options *StatusOptions) ([]*MachineStatus, error) {
+	err := api.checkPolicy("status", options.WorkingDirectory, options.Targets...)
+	if err != nil {
+		return nil, err
+	}
+
+	outputLines, err := api.client.withRetry(func() ([]*vagrantOutputLine, error) {
+		return api.executeInWorkingDirectory(options.WorkingDirectory, statusArgs(options)...)
+	})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "command execution failed")
+	}
+
+	return machineStatusesFromOutputLines(outputLines), nil
+}
+
+func statusArgs(options *StatusOptions) []string {
+	args := []string{
+		"status",
+	}
+
+	return append(args, options.Targets...)
+}
+
+func machineStatusesFromOutputLines(outputLines []*vagrantOutputLine) []*MachineStatus {
+	// NOTE: Use 0 element slice in case there's nothing to return
+	//noinspection GoPreferNilSlice
+	statuses := []*MachineStatus{}
+	statusesByName := map[string]*MachineStatus{}
+
+	for _, line := range outputLines {
+		if len(line.target) == 0 || len(line.data) < 1 {
+			continue
+		}
+
+		status, ok := statusesByName[line.target]
+		if !ok {
+			status = &MachineStatus{
+				Name: line.target,
+			}
+		}
+
+		switch line.kind {
+		case "provider-name":
+			status.Provider = line.data[0]
+		case "state":
+			status.State = line.data[0]
+		case "state-human-short":
+			status.StateHumanShort = unescapeMachineReadable(line.data[0])
+		case "state-human-long":
+			status.StateHumanLong = unescapeMachineReadable(line.data[0])
+		default:
+			continue
+		}
+
+		if !ok {
+			statusesByName[line.target] = status
+			statuses = append(statuses, status)
+		}
+	}
+
+	for _, status := range statuses {
+		status.NormalizedState = NormalizeMachineState(status.Provider, status.State)
+	}
+
+	return statuses
+}
+
+func (api *globalAPI) Halt(options *HaltOptions) error {
+	err := api.checkPolicy("halt", options.WorkingDirectory, options.Targets...)
+	if err != nil {
+		return err
+	}
+
+	_, err = api.executeInWorkingDirectory(options.WorkingDirectory, haltArgs(options)...)
+	return err
+}
+
+func haltArgs(options *HaltOptions) []string {
+	args := []string{
+		"halt",
+	}
+
+	if options.Force {
+		args = append(args, "--force")
+	}
+
+	return append(args, options.Targets...)
+}
+
+func (api *globalAPI) Resume(options *ResumeOptions) error {
+	err := api.checkPolicy("resume", options.WorkingDirectory, options.Targets...)
+	if err != nil {
+		return err
+	}
+
+	_, err = api.executeInWorkingDirectory(options.WorkingDirectory, resumeArgs(options)...)
+	return err
+}
+
+func resumeArgs(options *ResumeOptions) []string {
+	args := []string{
+		"resume",
+	}
+
+	return append(args, options.Targets...)
+}
+
+func (api *globalAPI) Reload(options *ReloadOptions) (*ReloadResult, error) {
+	err := api.checkPolicy("reload", options.WorkingDirectory, options.Targets...)
+	if err != nil {
+		return nil, err
+	}
+
+	outputLines, queueWait, err := api.executeWithConcurrencyLimit(options.WorkingDirectory, reloadArgs(options)...)
+	if outputLines == nil && !isConcurrencyLimitTimeout(err) {
+		return nil, err
+	}
+
+	result := &ReloadResult{
+		Machines:  machineResultsFromOutputLines(outputLines, "reload", "running"),
+		Timeline:  timelineFromOutputLines(outputLines, "reload"),
+		QueueWait: queueWait,
+	}
+
+	return result, err
+}
+
+func reloadArgs(options *ReloadOptions) []string {
+	args := []string{
+		"reload",
+	}
+
+	if options.Provision {
+		args = append(args, "--provision")
+	} else {
+		args = append(args, "--no-provision")
+	}
+
+	if len(options.ProvisionWith) > 0 {
+		args = append(args, "--provision-with", strings.Join(options.ProvisionWith, ","))
+	}
+
+	return append(args, options.Targets...)
+}
+
+func (api *globalAPI) Provision(options *ProvisionOptions) (*ProvisionResult, error) {
+	err := api.checkPolicy("provision", options.WorkingDirectory, options.Targets...)
+	if err != nil {
+		return nil, err
+	}
+
+	outputLines, err := api.executeWithAllLinesInWorkingDirectory(options.WorkingDirectory, provisionArgs(options)...)
+	if outputLines == nil {
+		return nil, err
+	}
+
+	result := &ProvisionResult{
+		Machines: machineResultsFromOutputLines(outputLines, "provision", "running"),
+		Timeline: timelineFromOutputLines(outputLines, "provision"),
+	}
+
+	return result, err
+}
+
+func provisionArgs(options *ProvisionOptions) []string {
+	args := []string{
+		"provision",
+	}
+
+	if len(options.ProvisionWith) > 0 {
+		args = append(args, "--provision-with", strings.Join(options.ProvisionWith, ","))
+	}
+
+	return append(args, options.Targets...)
+}
+
+func (api *globalAPI) SshExec(machine string, command string, options *SshExecOptions) (string, error) {
+	err := api.checkPolicy("ssh", options.WorkingDirectory, machine)
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{
+		"ssh",
+		"--command", command,
+	}
+
+	if len(machine) > 0 {
+		args = append(args, machine)
+	}
+
+	var output []byte
+
+	// NOTE: `vagrant ssh` replaces itself with the `ssh` process, so its output is not machine readable
+	err = api.inWorkingDirectory(options.WorkingDirectory, func() error {
+		var err error
+		output, err = api.client.runVagrantCommand(args...)
+		return err
+	})
+
+	return string(output), err
+}
+
+const defaultVagrantfileName = "Vagrantfile"
+
+func (api *globalAPI) Init(options *InitOptions) error {
+	err := api.checkPolicy("init", options.WorkingDirectory)
+	if err != nil {
+		return err
+	}
+
+	args, err := initArgs(options)
+	if err != nil {
+		return err
+	}
+
+	if !options.Force {
+		err := api.ensureVagrantfileDoesNotExist(options)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = api.executeInWorkingDirectory(options.WorkingDirectory, args...)
+	return err
+}
+
+func initArgs(options *InitOptions) ([]string, error) {
+	if len(options.BoxURL) > 0 && len(options.BoxName) == 0 {
+		return nil, stacktrace.NewError("`BoxURL` requires `BoxName` to be set")
+	}
+
+	args := []string{
+		"init",
+	}
+
+	if len(options.BoxVersion) > 0 {
+		args = append(args, "--box-version", options.BoxVersion)
+	}
+
+	if options.Force {
+		args = append(args, "--force")
+	}
+
+	if options.Minimal {
+		args = append(args, "--minimal")
+	}
+
+	if len(options.Output) > 0 {
+		args = append(args, "--output", options.Output)
+	}
+
+	if len(options.Template) > 0 {
+		args = append(args, "--template", options.Template)
+	}
+
+	if len(options.BoxName) > 0 {
+		args = append(args, options.BoxName)
+	}
+
+	if len(options.BoxURL) > 0 {
+		args = append(args, options.BoxURL)
+	}
+
+	return args, nil
+}
+
+func (api *globalAPI) ensureVagrantfileDoesNotExist(options *InitOptions) error {
+	vagrantfilePath := options.Output
+	if len(vagrantfilePath) == 0 {
+		vagrantfilePath = defaultVagrantfileName
+	}
+
+	// NOTE: Vagrantfile is written to stdout, there's nothing to overwrite
+	if vagrantfilePath == "-" {
+		return nil
+	}
+
+	if !filepath.IsAbs(vagrantfilePath) && len(options.WorkingDirectory) > 0 {
+		vagrantfilePath = filepath.Join(options.WorkingDirectory, vagrantfilePath)
+	}
+
+	_, err := api.osExecutor.Stat(vagrantfilePath)
+	if err == nil {
+		return stacktrace.NewErrorWithCode(
+			ErrorCodeVagrantfileExists,
+			"`%s` already exists, use `Force` to overwrite it",
+			vagrantfilePath,
+		)
+	}
+
+	if !os.IsNotExist(err) {
+		return stacktrace.Propagate(err, "failed to check whether `%s` exists", vagrantfilePath)
+	}
+
+	return nil
 }
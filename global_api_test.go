@@ -2,10 +2,13 @@ package vagrant_go
 
 import (
 	"errors"
+	"github.com/palantir/stacktrace"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"os"
 	"testing"
+	"time"
 )
 
 func TestDefaultUpOptions(t *testing.T) {
@@ -20,6 +23,7 @@ func TestDefaultUpOptions(t *testing.T) {
 	assert.True(t, options.Parallel)
 	assert.Equal(t, options.Provider, "")
 	assert.True(t, options.InstallProvider)
+	assert.Empty(t, options.Targets)
 }
 
 func TestDefaultDestroyOptions(t *testing.T) {
@@ -30,6 +34,7 @@ func TestDefaultDestroyOptions(t *testing.T) {
 	assert.Equal(t, options.WorkingDirectory, "")
 	assert.True(t, options.Force)
 	assert.True(t, options.Parallel)
+	assert.Empty(t, options.Targets)
 }
 
 func TestDefaultSshConfigOptions(t *testing.T) {
@@ -41,6 +46,87 @@ func TestDefaultSshConfigOptions(t *testing.T) {
 	assert.Equal(t, options.Name, "")
 }
 
+func TestDefaultValidateOptions(t *testing.T) {
+	t.Parallel()
+
+	options := DefaultValidateOptions()
+
+	assert.Equal(t, options.WorkingDirectory, "")
+	assert.Equal(t, options.Name, "")
+	assert.True(t, options.IgnoreProvider)
+}
+
+func TestDefaultInitOptions(t *testing.T) {
+	t.Parallel()
+
+	options := DefaultInitOptions()
+
+	assert.Equal(t, options.WorkingDirectory, "")
+	assert.Equal(t, options.BoxName, "")
+	assert.Equal(t, options.BoxURL, "")
+	assert.Equal(t, options.BoxVersion, "")
+	assert.False(t, options.Force)
+	assert.False(t, options.Minimal)
+	assert.Equal(t, options.Output, "")
+	assert.Equal(t, options.Template, "")
+}
+
+func TestDefaultUploadOptions(t *testing.T) {
+	t.Parallel()
+
+	options := DefaultUploadOptions()
+
+	assert.Equal(t, options.WorkingDirectory, "")
+	assert.False(t, options.Compress)
+	assert.Equal(t, options.CompressionType, "")
+	assert.False(t, options.Temporary)
+}
+
+func TestDefaultWinrmConfigOptions(t *testing.T) {
+	t.Parallel()
+
+	options := DefaultWinrmConfigOptions()
+
+	assert.Equal(t, options.WorkingDirectory, "")
+	assert.Equal(t, options.Host, "")
+}
+
+func TestDefaultStatusOptions(t *testing.T) {
+	t.Parallel()
+
+	options := DefaultStatusOptions()
+
+	assert.Equal(t, options.WorkingDirectory, "")
+	assert.Empty(t, options.Targets)
+}
+
+func TestDefaultHaltOptions(t *testing.T) {
+	t.Parallel()
+
+	options := DefaultHaltOptions()
+
+	assert.Equal(t, options.WorkingDirectory, "")
+	assert.False(t, options.Force)
+	assert.Empty(t, options.Targets)
+}
+
+func TestDefaultResumeOptions(t *testing.T) {
+	t.Parallel()
+
+	options := DefaultResumeOptions()
+
+	assert.Equal(t, options.WorkingDirectory, "")
+	assert.Empty(t, options.Targets)
+}
+
+func TestDefaultSshExecOptions(t *testing.T) {
+	t.Parallel()
+
+	options := DefaultSshExecOptions()
+
+	assert.Equal(t, options.WorkingDirectory, "")
+}
+
 func TestGlobalAPI_Up(t *testing.T) {
 	t.Run(
 		"with default options and no execution error, it executes command and does not change current working dir before execution",
@@ -49,6 +135,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			fakeOsExecutor := &fakeOsExecutor{}
 
 			client := emptyTestClient(t)
+			client.version = testVersion
 			globalAPI := &globalAPI{
 				osExecutor: fakeOsExecutor,
 				client:     client,
@@ -71,7 +158,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			}
 
 			options := DefaultUpOptions()
-			err := client.Global.Up(options)
+			_, err := client.Global.Up(options)
 			require.NoError(t, err)
 
 			assert.True(t, isCommandRunCalled)
@@ -90,6 +177,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			fakeOsExecutor.On("Getwd").Return("", fakeError)
 
 			client := emptyTestClient(t)
+			client.version = testVersion
 			globalAPI := &globalAPI{
 				osExecutor: fakeOsExecutor,
 				client:     client,
@@ -104,7 +192,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 
 			options := DefaultUpOptions()
 			options.WorkingDirectory = "/tmp/example"
-			err := client.Global.Up(options)
+			_, err := client.Global.Up(options)
 			assert.Error(t, err, "fake error")
 
 			assert.False(t, isCommandRunCalled)
@@ -126,6 +214,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			fakeOsExecutor.On("Chdir", fakeOptionsWd).Return(fakeError)
 
 			client := emptyTestClient(t)
+			client.version = testVersion
 			globalAPI := &globalAPI{
 				osExecutor: fakeOsExecutor,
 				client:     client,
@@ -141,7 +230,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			options := DefaultUpOptions()
 			options.WorkingDirectory = fakeOptionsWd
 
-			err := client.Global.Up(options)
+			_, err := client.Global.Up(options)
 			assert.Error(t, err, "fake error")
 
 			assert.False(t, isCommandRunCalled)
@@ -166,6 +255,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			fakeOsExecutor.On("Chdir", fakeCwd).Return(fakeError)
 
 			client := emptyTestClient(t)
+			client.version = testVersion
 			globalAPI := &globalAPI{
 				osExecutor: fakeOsExecutor,
 				client:     client,
@@ -181,7 +271,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			options := DefaultUpOptions()
 			options.WorkingDirectory = fakeOptionsWd
 
-			err := client.Global.Up(options)
+			_, err := client.Global.Up(options)
 			assert.Error(t, err, "fake error")
 
 			assert.True(t, isCommandRunCalled)
@@ -198,6 +288,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			fakeOsExecutor := &fakeOsExecutor{}
 
 			client := emptyTestClient(t)
+			client.version = testVersion
 			globalAPI := &globalAPI{
 				osExecutor: fakeOsExecutor,
 				client:     client,
@@ -222,7 +313,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			options := DefaultUpOptions()
 			options.Provision = true
 
-			err := client.Global.Up(options)
+			_, err := client.Global.Up(options)
 			assert.NoError(t, err)
 
 			assert.True(t, isCommandRunCalled)
@@ -236,6 +327,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			fakeOsExecutor := &fakeOsExecutor{}
 
 			client := emptyTestClient(t)
+			client.version = testVersion
 			globalAPI := &globalAPI{
 				osExecutor: fakeOsExecutor,
 				client:     client,
@@ -260,7 +352,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			options := DefaultUpOptions()
 			options.Provision = false
 
-			err := client.Global.Up(options)
+			_, err := client.Global.Up(options)
 			assert.NoError(t, err)
 
 			assert.True(t, isCommandRunCalled)
@@ -274,6 +366,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			fakeOsExecutor := &fakeOsExecutor{}
 
 			client := emptyTestClient(t)
+			client.version = testVersion
 			globalAPI := &globalAPI{
 				osExecutor: fakeOsExecutor,
 				client:     client,
@@ -300,7 +393,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			options := DefaultUpOptions()
 			options.ProvisionWith = []string{"shell"}
 
-			err := client.Global.Up(options)
+			_, err := client.Global.Up(options)
 			assert.NoError(t, err)
 
 			assert.True(t, isCommandRunCalled)
@@ -314,6 +407,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			fakeOsExecutor := &fakeOsExecutor{}
 
 			client := emptyTestClient(t)
+			client.version = testVersion
 			globalAPI := &globalAPI{
 				osExecutor: fakeOsExecutor,
 				client:     client,
@@ -338,7 +432,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			options := DefaultUpOptions()
 			options.DestroyOnError = true
 
-			err := client.Global.Up(options)
+			_, err := client.Global.Up(options)
 			assert.NoError(t, err)
 
 			assert.True(t, isCommandRunCalled)
@@ -352,6 +446,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			fakeOsExecutor := &fakeOsExecutor{}
 
 			client := emptyTestClient(t)
+			client.version = testVersion
 			globalAPI := &globalAPI{
 				osExecutor: fakeOsExecutor,
 				client:     client,
@@ -376,7 +471,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			options := DefaultUpOptions()
 			options.DestroyOnError = false
 
-			err := client.Global.Up(options)
+			_, err := client.Global.Up(options)
 			assert.NoError(t, err)
 
 			assert.True(t, isCommandRunCalled)
@@ -390,6 +485,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			fakeOsExecutor := &fakeOsExecutor{}
 
 			client := emptyTestClient(t)
+			client.version = testVersion
 			globalAPI := &globalAPI{
 				osExecutor: fakeOsExecutor,
 				client:     client,
@@ -414,7 +510,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			options := DefaultUpOptions()
 			options.Parallel = true
 
-			err := client.Global.Up(options)
+			_, err := client.Global.Up(options)
 			assert.NoError(t, err)
 
 			assert.True(t, isCommandRunCalled)
@@ -428,6 +524,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			fakeOsExecutor := &fakeOsExecutor{}
 
 			client := emptyTestClient(t)
+			client.version = testVersion
 			globalAPI := &globalAPI{
 				osExecutor: fakeOsExecutor,
 				client:     client,
@@ -452,7 +549,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			options := DefaultUpOptions()
 			options.Parallel = false
 
-			err := client.Global.Up(options)
+			_, err := client.Global.Up(options)
 			assert.NoError(t, err)
 
 			assert.True(t, isCommandRunCalled)
@@ -466,6 +563,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			fakeOsExecutor := &fakeOsExecutor{}
 
 			client := emptyTestClient(t)
+			client.version = testVersion
 			globalAPI := &globalAPI{
 				osExecutor: fakeOsExecutor,
 				client:     client,
@@ -492,7 +590,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			options := DefaultUpOptions()
 			options.Provider = "libvirt"
 
-			err := client.Global.Up(options)
+			_, err := client.Global.Up(options)
 			assert.NoError(t, err)
 
 			assert.True(t, isCommandRunCalled)
@@ -506,6 +604,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			fakeOsExecutor := &fakeOsExecutor{}
 
 			client := emptyTestClient(t)
+			client.version = testVersion
 			globalAPI := &globalAPI{
 				osExecutor: fakeOsExecutor,
 				client:     client,
@@ -530,7 +629,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			options := DefaultUpOptions()
 			options.Provider = ""
 
-			err := client.Global.Up(options)
+			_, err := client.Global.Up(options)
 			assert.NoError(t, err)
 
 			assert.True(t, isCommandRunCalled)
@@ -544,6 +643,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			fakeOsExecutor := &fakeOsExecutor{}
 
 			client := emptyTestClient(t)
+			client.version = testVersion
 			globalAPI := &globalAPI{
 				osExecutor: fakeOsExecutor,
 				client:     client,
@@ -568,7 +668,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			options := DefaultUpOptions()
 			options.InstallProvider = true
 
-			err := client.Global.Up(options)
+			_, err := client.Global.Up(options)
 			assert.NoError(t, err)
 
 			assert.True(t, isCommandRunCalled)
@@ -584,6 +684,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 
 		isCommandRunCalled := false
 		client := emptyTestClient(t)
+		client.version = testVersion
 		client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
 			isCommandRunCalled = true
 			return []byte{}, errors.New("fake error")
@@ -597,7 +698,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 
 		options := DefaultUpOptions()
 		options.WorkingDirectory = "/tmp/example"
-		err := client.Global.Up(options)
+		_, err := client.Global.Up(options)
 		assert.Error(t, err, "fake error")
 
 		assert.True(t, isCommandRunCalled)
@@ -610,6 +711,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			fakeOsExecutor := &fakeOsExecutor{}
 
 			client := emptyTestClient(t)
+			client.version = testVersion
 			globalAPI := &globalAPI{
 				osExecutor: fakeOsExecutor,
 				client:     client,
@@ -634,7 +736,7 @@ func TestGlobalAPI_Up(t *testing.T) {
 			options := DefaultUpOptions()
 			options.InstallProvider = false
 
-			err := client.Global.Up(options)
+			_, err := client.Global.Up(options)
 			assert.NoError(t, err)
 
 			assert.True(t, isCommandRunCalled)
@@ -642,6 +744,96 @@ func TestGlobalAPI_Up(t *testing.T) {
 	)
 }
 
+func TestGlobalAPI_Up_Targets(t *testing.T) {
+	t.Run(
+		"with options providing 'Targets', it executes command with targets and returns result per targeted machine",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+			isCommandRunCalled := false
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{
+					"--machine-readable",
+					"up",
+					"--provision",
+					"--destroy-on-error",
+					"--parallel",
+					"--install-provider",
+					"db",
+					"/web\\d/",
+				}, args)
+				isCommandRunCalled = true
+
+				output := `
+1546430404,db,metadata,provider,libvirt
+1546430404,web1,metadata,provider,libvirt
+1546430404,web2,metadata,provider,libvirt
+1546430404,,ui,info,Bringing machine 'db' up with 'libvirt' provider...
+1546430404,db,action,up,start
+1546430405,web1,action,up,start
+1546430406,db,action,up,end
+1546430407,web1,action,up,end
+`
+				return []byte(output), nil
+			}
+
+			options := DefaultUpOptions()
+			options.Targets = []string{"db", "/web\\d/"}
+
+			result, err := client.Global.Up(options)
+			require.NoError(t, err)
+			assert.True(t, isCommandRunCalled)
+
+			require.NotNil(t, result)
+			require.Len(t, result.Machines, 3)
+
+			assert.Equal(t, "db", result.Machines[0].Name)
+			assert.Equal(t, MachineOutcomeSucceeded, result.Machines[0].Outcome)
+			assert.Equal(t, "libvirt", result.Machines[0].Provider)
+			assert.Equal(t, "running", result.Machines[0].State)
+			assert.Equal(t, 2*time.Second, result.Machines[0].Duration)
+
+			assert.Equal(t, "web1", result.Machines[1].Name)
+			assert.Equal(t, MachineOutcomeSucceeded, result.Machines[1].Outcome)
+
+			assert.Equal(t, "web2", result.Machines[2].Name)
+			assert.Equal(t, MachineOutcomeSkipped, result.Machines[2].Outcome)
+			assert.Equal(t, "", result.Machines[2].State)
+		},
+	)
+
+	t.Run(
+		"with command execution returning an error, it returns an error and result with failed machine",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				output := `
+1546430404,db,metadata,provider,libvirt
+1546430404,db,action,up,start
+1546430404,,error-exit,Vagrant::Errors::BoxNotFound,Box not found
+`
+				return []byte(output), errors.New("fake error")
+			}
+
+			result, err := client.Global.Up(DefaultUpOptions())
+			assert.Error(t, err, "fake error")
+
+			require.NotNil(t, result)
+			require.Len(t, result.Machines, 1)
+			assert.Equal(t, "db", result.Machines[0].Name)
+			assert.Equal(t, MachineOutcomeFailed, result.Machines[0].Outcome)
+			assert.Equal(t, "", result.Machines[0].State)
+		},
+	)
+}
+
 func TestGlobalAPI_Destroy(t *testing.T) {
 	t.Run(
 		"with options providing 'Force' = true, it executes command with '--force'",
@@ -672,7 +864,7 @@ func TestGlobalAPI_Destroy(t *testing.T) {
 
 			options := DefaultDestroyOptions()
 			options.Force = true
-			err := client.Global.Destroy(options)
+			_, err := client.Global.Destroy(options)
 			require.NoError(t, err)
 			assert.True(t, isCommandRunCalled)
 		},
@@ -706,7 +898,7 @@ func TestGlobalAPI_Destroy(t *testing.T) {
 
 			options := DefaultDestroyOptions()
 			options.Force = false
-			err := client.Global.Destroy(options)
+			_, err := client.Global.Destroy(options)
 			require.NoError(t, err)
 			assert.True(t, isCommandRunCalled)
 		},
@@ -741,7 +933,7 @@ func TestGlobalAPI_Destroy(t *testing.T) {
 
 			options := DefaultDestroyOptions()
 			options.Parallel = true
-			err := client.Global.Destroy(options)
+			_, err := client.Global.Destroy(options)
 			require.NoError(t, err)
 			assert.True(t, isCommandRunCalled)
 		},
@@ -776,7 +968,7 @@ func TestGlobalAPI_Destroy(t *testing.T) {
 
 			options := DefaultDestroyOptions()
 			options.Parallel = false
-			err := client.Global.Destroy(options)
+			_, err := client.Global.Destroy(options)
 			require.NoError(t, err)
 			assert.True(t, isCommandRunCalled)
 		},
@@ -809,7 +1001,7 @@ func TestGlobalAPI_Destroy(t *testing.T) {
 			}
 
 			options := DefaultDestroyOptions()
-			err := client.Global.Destroy(options)
+			_, err := client.Global.Destroy(options)
 			require.NoError(t, err)
 
 			assert.True(t, isCommandRunCalled)
@@ -828,6 +1020,7 @@ func TestGlobalAPI_Destroy(t *testing.T) {
 			fakeOsExecutor.On("Getwd").Return("", fakeError)
 
 			client := emptyTestClient(t)
+			client.version = testVersion
 			globalAPI := &globalAPI{
 				osExecutor: fakeOsExecutor,
 				client:     client,
@@ -842,7 +1035,7 @@ func TestGlobalAPI_Destroy(t *testing.T) {
 
 			options := DefaultUpOptions()
 			options.WorkingDirectory = "/tmp/example"
-			err := client.Global.Up(options)
+			_, err := client.Global.Up(options)
 			assert.Error(t, err, "fake error")
 
 			assert.False(t, isCommandRunCalled)
@@ -879,7 +1072,7 @@ func TestGlobalAPI_Destroy(t *testing.T) {
 			options := DefaultDestroyOptions()
 			options.WorkingDirectory = fakeOptionsWd
 
-			err := client.Global.Destroy(options)
+			_, err := client.Global.Destroy(options)
 			assert.Error(t, err, "fake error")
 
 			assert.False(t, isCommandRunCalled)
@@ -919,7 +1112,7 @@ func TestGlobalAPI_Destroy(t *testing.T) {
 			options := DefaultDestroyOptions()
 			options.WorkingDirectory = fakeOptionsWd
 
-			err := client.Global.Destroy(options)
+			_, err := client.Global.Destroy(options)
 			assert.Error(t, err, "fake error")
 
 			assert.True(t, isCommandRunCalled)
@@ -930,6 +1123,40 @@ func TestGlobalAPI_Destroy(t *testing.T) {
 	)
 }
 
+func TestGlobalAPI_Destroy_Targets(t *testing.T) {
+	t.Parallel()
+
+	client := emptyTestClient(t)
+
+	client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+		assert.Equal(t, []string{"--machine-readable", "destroy", "--force", "--parallel", "db"}, args)
+
+		output := `
+1546430404,db,metadata,provider,libvirt
+1546430404,db,action,destroy,start
+1546430405,db,action,destroy,end
+`
+		return []byte(output), nil
+	}
+
+	options := DefaultDestroyOptions()
+	options.Targets = []string{"db"}
+
+	result, err := client.Global.Destroy(options)
+	require.NoError(t, err)
+
+	require.NotNil(t, result)
+	require.Len(t, result.Machines, 1)
+	assert.Equal(t, &MachineResult{
+		Name:         "db",
+		Outcome:      MachineOutcomeSucceeded,
+		Provider:     "libvirt",
+		State:        "not_created",
+		Duration:     time.Second,
+		Provisioners: []string{},
+	}, result.Machines[0])
+}
+
 func TestGlobalAPI_SshConfig(t *testing.T) {
 	t.Run(
 		"with default options and no execution error, it executes command and does not change current working dir before execution",
@@ -1325,3 +1552,1102 @@ Host node2
 		},
 	)
 }
+
+func TestGlobalAPI_Validate(t *testing.T) {
+	t.Run(
+		"with default options and a valid Vagrantfile, it executes command with '--ignore-provider' and returns no validation errors",
+		func(t *testing.T) {
+			t.Parallel()
+			fakeOsExecutor := &fakeOsExecutor{}
+
+			client := emptyTestClient(t)
+			globalAPI := &globalAPI{
+				osExecutor: fakeOsExecutor,
+				client:     client,
+			}
+			client.Global = globalAPI
+			isCommandRunCalled := false
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, cmd, client.Config.BinaryName)
+				assert.Len(t, args, 3)
+				assert.Equal(t, args[0], "--machine-readable")
+				assert.Equal(t, args[1], "validate")
+				assert.Equal(t, args[2], "--ignore-provider")
+
+				isCommandRunCalled = true
+				return []byte("1547581456,,ui,info,Vagrantfile validated successfully."), nil
+			}
+
+			validationErrors, err := client.Global.Validate(DefaultValidateOptions())
+			require.NoError(t, err)
+			assert.Empty(t, validationErrors)
+
+			assert.True(t, isCommandRunCalled)
+			fakeOsExecutor.AssertNotCalled(t, "Getwd")
+			fakeOsExecutor.AssertNotCalled(t, "Chdir")
+		},
+	)
+
+	t.Run(
+		"with options providing 'Name' = web and an invalid Vagrantfile, it returns validation errors per section for the machine",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			isCommandRunCalled := false
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Len(t, args, 4)
+				assert.Equal(t, args[1], "validate")
+				assert.Equal(t, args[2], "--ignore-provider")
+				assert.Equal(t, args[3], "web")
+
+				isCommandRunCalled = true
+				output := `1547581456,,error-exit,Vagrant::Errors::ConfigInvalid,There are errors in the configuration of this machine. Please fix\nthe following errors and try again:\n\nvm:\n* A box must be specified.\n* The host path of the shared folder is missing: ./data\n\nssh:\n* private_key_path file must exist: /tmp/key%!(VAGRANT_COMMA) really\n\n`
+				return []byte(output), errors.New("exit status 1")
+			}
+
+			options := DefaultValidateOptions()
+			options.Name = "web"
+
+			validationErrors, err := client.Global.Validate(options)
+			require.NoError(t, err)
+			assert.True(t, isCommandRunCalled)
+			require.Len(t, validationErrors, 3)
+
+			assert.Equal(t, "web", validationErrors[0].Machine)
+			assert.Equal(t, "vm", validationErrors[0].Section)
+			assert.Equal(t, "A box must be specified.", validationErrors[0].Message)
+
+			assert.Equal(t, "web", validationErrors[1].Machine)
+			assert.Equal(t, "vm", validationErrors[1].Section)
+			assert.Equal(t, "The host path of the shared folder is missing: ./data", validationErrors[1].Message)
+
+			assert.Equal(t, "web", validationErrors[2].Machine)
+			assert.Equal(t, "ssh", validationErrors[2].Section)
+			assert.Equal(t, "private_key_path file must exist: /tmp/key, really", validationErrors[2].Message)
+		},
+	)
+
+	t.Run(
+		"with options providing 'IgnoreProvider' = false and command execution returning an unrelated error, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Len(t, args, 2)
+				assert.Equal(t, args[1], "validate")
+
+				output := `1547581456,,error-exit,Vagrant::Errors::NoEnvironmentError,A Vagrant environment or target machine is required to run this\ncommand.`
+				return []byte(output), errors.New("exit status 1")
+			}
+
+			options := DefaultValidateOptions()
+			options.IgnoreProvider = false
+
+			validationErrors, err := client.Global.Validate(options)
+			assert.Nil(t, validationErrors)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "command execution failed")
+		},
+	)
+
+	t.Run(
+		"with options providing 'WorkingDirectory', it executes command in it and changes back to current working dir",
+		func(t *testing.T) {
+			t.Parallel()
+			fakeOsExecutor := &fakeOsExecutor{}
+
+			fakeCwd := "/tmp/anotherexample"
+			fakeOsExecutor.On("Getwd").Return(fakeCwd, nil)
+
+			fakeOptionsWd := "/tmp/example"
+			fakeOsExecutor.On("Chdir", fakeOptionsWd).Return(nil)
+			fakeOsExecutor.On("Chdir", fakeCwd).Return(nil)
+
+			client := emptyTestClient(t)
+			globalAPI := &globalAPI{
+				osExecutor: fakeOsExecutor,
+				client:     client,
+			}
+			client.Global = globalAPI
+
+			options := DefaultValidateOptions()
+			options.WorkingDirectory = fakeOptionsWd
+
+			_, err := client.Global.Validate(options)
+			require.NoError(t, err)
+
+			fakeOsExecutor.AssertCalled(t, "Getwd")
+			fakeOsExecutor.AssertCalled(t, "Chdir", fakeOptionsWd)
+			fakeOsExecutor.AssertCalled(t, "Chdir", fakeCwd)
+		},
+	)
+}
+
+func TestGlobalAPI_Init(t *testing.T) {
+	t.Run(
+		"with options providing all fields and no existing Vagrantfile, it executes command in 'WorkingDirectory' with all flags, box name and url",
+		func(t *testing.T) {
+			t.Parallel()
+			fakeOsExecutor := &fakeOsExecutor{}
+
+			fakeCwd := "/tmp/anotherexample"
+			fakeOptionsWd := "/tmp/example"
+			fakeOsExecutor.On("Stat", "/tmp/example/Vagrantfile.custom").Return(nil, os.ErrNotExist)
+			fakeOsExecutor.On("Getwd").Return(fakeCwd, nil)
+			fakeOsExecutor.On("Chdir", fakeOptionsWd).Return(nil)
+			fakeOsExecutor.On("Chdir", fakeCwd).Return(nil)
+
+			client := emptyTestClient(t)
+			globalAPI := &globalAPI{
+				osExecutor: fakeOsExecutor,
+				client:     client,
+			}
+			client.Global = globalAPI
+			isCommandRunCalled := false
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{
+					"--machine-readable",
+					"init",
+					"--box-version", "1.2.3",
+					"--minimal",
+					"--output", "Vagrantfile.custom",
+					"--template", "/tmp/Vagrantfile.erb",
+					"my-debian",
+					"https://example.com/my-debian.box",
+				}, args)
+
+				isCommandRunCalled = true
+				return []byte{}, nil
+			}
+
+			options := DefaultInitOptions()
+			options.WorkingDirectory = fakeOptionsWd
+			options.BoxName = "my-debian"
+			options.BoxURL = "https://example.com/my-debian.box"
+			options.BoxVersion = "1.2.3"
+			options.Minimal = true
+			options.Output = "Vagrantfile.custom"
+			options.Template = "/tmp/Vagrantfile.erb"
+
+			err := client.Global.Init(options)
+			require.NoError(t, err)
+
+			assert.True(t, isCommandRunCalled)
+			fakeOsExecutor.AssertCalled(t, "Chdir", fakeOptionsWd)
+			fakeOsExecutor.AssertCalled(t, "Chdir", fakeCwd)
+		},
+	)
+
+	t.Run(
+		"with an existing Vagrantfile and 'Force' = false, it does not execute command and returns an error with 'ErrorCodeVagrantfileExists'",
+		func(t *testing.T) {
+			t.Parallel()
+			fakeOsExecutor := &fakeOsExecutor{}
+			fakeOsExecutor.On("Stat", "/tmp/example/Vagrantfile").Return(nil, nil)
+
+			client := emptyTestClient(t)
+			globalAPI := &globalAPI{
+				osExecutor: fakeOsExecutor,
+				client:     client,
+			}
+			client.Global = globalAPI
+			isCommandRunCalled := false
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				isCommandRunCalled = true
+				return []byte{}, nil
+			}
+
+			options := DefaultInitOptions()
+			options.WorkingDirectory = "/tmp/example"
+
+			err := client.Global.Init(options)
+			require.Error(t, err)
+			assert.Equal(t, ErrorCodeVagrantfileExists, stacktrace.GetCode(err))
+
+			assert.False(t, isCommandRunCalled)
+			fakeOsExecutor.AssertNotCalled(t, "Chdir")
+		},
+	)
+
+	t.Run(
+		"with an existing Vagrantfile and 'Force' = true, it executes command with '--force' without checking for the Vagrantfile",
+		func(t *testing.T) {
+			t.Parallel()
+			fakeOsExecutor := &fakeOsExecutor{}
+
+			client := emptyTestClient(t)
+			globalAPI := &globalAPI{
+				osExecutor: fakeOsExecutor,
+				client:     client,
+			}
+			client.Global = globalAPI
+			isCommandRunCalled := false
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{"--machine-readable", "init", "--force", "my-debian"}, args)
+
+				isCommandRunCalled = true
+				return []byte{}, nil
+			}
+
+			options := DefaultInitOptions()
+			options.BoxName = "my-debian"
+			options.Force = true
+
+			err := client.Global.Init(options)
+			require.NoError(t, err)
+
+			assert.True(t, isCommandRunCalled)
+			fakeOsExecutor.AssertNotCalled(t, "Stat")
+		},
+	)
+
+	t.Run(
+		"with options providing 'Output' = -, it executes command without checking for the Vagrantfile",
+		func(t *testing.T) {
+			t.Parallel()
+			fakeOsExecutor := &fakeOsExecutor{}
+
+			client := emptyTestClient(t)
+			globalAPI := &globalAPI{
+				osExecutor: fakeOsExecutor,
+				client:     client,
+			}
+			client.Global = globalAPI
+
+			options := DefaultInitOptions()
+			options.Output = "-"
+
+			err := client.Global.Init(options)
+			require.NoError(t, err)
+
+			fakeOsExecutor.AssertNotCalled(t, "Stat")
+		},
+	)
+
+	t.Run(
+		"with options providing 'BoxURL' without 'BoxName', it does not execute command and returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			isCommandRunCalled := false
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				isCommandRunCalled = true
+				return []byte{}, nil
+			}
+
+			options := DefaultInitOptions()
+			options.BoxURL = "https://example.com/my-debian.box"
+
+			err := client.Global.Init(options)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "`BoxURL` requires `BoxName`")
+
+			assert.False(t, isCommandRunCalled)
+		},
+	)
+}
+
+func TestGlobalAPI_Upload(t *testing.T) {
+	t.Run(
+		"with a file source, destination and machine, it executes command in 'WorkingDirectory' and returns the reported destination",
+		func(t *testing.T) {
+			t.Parallel()
+			fakeOsExecutor := &fakeOsExecutor{}
+
+			fakeCwd := "/tmp/anotherexample"
+			fakeOptionsWd := "/tmp/example"
+			fakeOsExecutor.On("Stat", "/tmp/example/setup.sh").Return(&fakeFileInfo{isDir: false}, nil)
+			fakeOsExecutor.On("Getwd").Return(fakeCwd, nil)
+			fakeOsExecutor.On("Chdir", fakeOptionsWd).Return(nil)
+			fakeOsExecutor.On("Chdir", fakeCwd).Return(nil)
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+			globalAPI := &globalAPI{
+				osExecutor: fakeOsExecutor,
+				client:     client,
+			}
+			client.Global = globalAPI
+			isCommandRunCalled := false
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{"--machine-readable", "upload", "setup.sh", "/tmp/setup.sh", "web"}, args)
+
+				isCommandRunCalled = true
+				output := `
+1547581456,,ui,info,Uploading file setup.sh to /tmp/setup.sh
+1547581457,,ui,info,Upload has completed successfully!
+`
+				return []byte(output), nil
+			}
+
+			options := DefaultUploadOptions()
+			options.WorkingDirectory = fakeOptionsWd
+
+			destination, err := client.Global.Upload("web", "setup.sh", "/tmp/setup.sh", options)
+			require.NoError(t, err)
+			assert.Equal(t, "/tmp/setup.sh", destination)
+
+			assert.True(t, isCommandRunCalled)
+			fakeOsExecutor.AssertCalled(t, "Chdir", fakeCwd)
+		},
+	)
+
+	t.Run(
+		"with a directory source and options providing 'Temporary' and 'CompressionType', it executes command with '--temporary', '--compress', '--compression-type' and returns the generated destination",
+		func(t *testing.T) {
+			t.Parallel()
+			fakeOsExecutor := &fakeOsExecutor{}
+			fakeOsExecutor.On("Stat", "/tmp/data").Return(&fakeFileInfo{isDir: true}, nil)
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+			globalAPI := &globalAPI{
+				osExecutor: fakeOsExecutor,
+				client:     client,
+			}
+			client.Global = globalAPI
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{
+					"--machine-readable",
+					"upload",
+					"--temporary",
+					"--compress",
+					"--compression-type", "zip",
+					"/tmp/data",
+					"web",
+				}, args)
+
+				output := `1547581456,,ui,info,Uploading directory /tmp/data to /tmp/vagrant-upload-20190115-1234-abcd`
+				return []byte(output), nil
+			}
+
+			options := DefaultUploadOptions()
+			options.Temporary = true
+			options.CompressionType = "zip"
+
+			destination, err := client.Global.Upload("web", "/tmp/data", "/ignored", options)
+			require.NoError(t, err)
+			assert.Equal(t, "/tmp/vagrant-upload-20190115-1234-abcd", destination)
+		},
+	)
+
+	t.Run(
+		"with options providing 'Temporary' and generated destination not reported, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+			fakeOsExecutor := &fakeOsExecutor{}
+			fakeOsExecutor.On("Stat", "/tmp/setup.sh").Return(&fakeFileInfo{isDir: false}, nil)
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+			globalAPI := &globalAPI{
+				osExecutor: fakeOsExecutor,
+				client:     client,
+			}
+			client.Global = globalAPI
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				return []byte(`1547581456,,ui,info,Uploading file /tmp/other.sh to /tmp/vagrant-upload-20190115-1234-abcd`), nil
+			}
+
+			options := DefaultUploadOptions()
+			options.Temporary = true
+
+			destination, err := client.Global.Upload("web", "/tmp/setup.sh", "", options)
+			require.Error(t, err)
+			assert.Equal(t, "", destination)
+			assert.Contains(t, err.Error(), "temporary destination of upload to machine `web` not reported")
+		},
+	)
+
+	t.Run(
+		"with machine given and no destination, it executes command with source base name as destination",
+		func(t *testing.T) {
+			t.Parallel()
+			fakeOsExecutor := &fakeOsExecutor{}
+			fakeOsExecutor.On("Stat", "/tmp/setup.sh").Return(&fakeFileInfo{isDir: false}, nil)
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+			globalAPI := &globalAPI{
+				osExecutor: fakeOsExecutor,
+				client:     client,
+			}
+			client.Global = globalAPI
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{"--machine-readable", "upload", "/tmp/setup.sh", "setup.sh", "web"}, args)
+				return []byte{}, nil
+			}
+
+			destination, err := client.Global.Upload("web", "/tmp/setup.sh", "", DefaultUploadOptions())
+			require.NoError(t, err)
+			assert.Equal(t, "setup.sh", destination)
+		},
+	)
+
+	t.Run(
+		"with guest missing a required capability, it returns an error with 'ErrorCodeUploadNotSupported'",
+		func(t *testing.T) {
+			t.Parallel()
+			fakeOsExecutor := &fakeOsExecutor{}
+			fakeOsExecutor.On("Stat", "/tmp/data").Return(&fakeFileInfo{isDir: true}, nil)
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+			globalAPI := &globalAPI{
+				osExecutor: fakeOsExecutor,
+				client:     client,
+			}
+			client.Global = globalAPI
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				output := `1547581456,,error-exit,Vagrant::Errors::UploadMissingTempCapability,The guest does not support temporary paths.`
+				return []byte(output), errors.New("exit status 1")
+			}
+
+			options := DefaultUploadOptions()
+			options.Temporary = true
+
+			destination, err := client.Global.Upload("web", "/tmp/data", "", options)
+			require.Error(t, err)
+			assert.Equal(t, "", destination)
+			assert.Equal(t, ErrorCodeUploadNotSupported, stacktrace.GetCode(err))
+			assert.Contains(t, err.Error(), "The guest does not support temporary paths.")
+		},
+	)
+
+	t.Run(
+		"with missing source, it does not execute command and returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+			fakeOsExecutor := &fakeOsExecutor{}
+			fakeOsExecutor.On("Stat", "/tmp/missing").Return(nil, os.ErrNotExist)
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+			globalAPI := &globalAPI{
+				osExecutor: fakeOsExecutor,
+				client:     client,
+			}
+			client.Global = globalAPI
+			isCommandRunCalled := false
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				isCommandRunCalled = true
+				return []byte{}, nil
+			}
+
+			_, err := client.Global.Upload("web", "/tmp/missing", "", DefaultUploadOptions())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "failed to stat upload source")
+			assert.False(t, isCommandRunCalled)
+		},
+	)
+
+	t.Run(
+		"with unsupported 'CompressionType', it does not execute command and returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+			fakeOsExecutor := &fakeOsExecutor{}
+			fakeOsExecutor.On("Stat", "/tmp/data").Return(&fakeFileInfo{isDir: true}, nil)
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+			globalAPI := &globalAPI{
+				osExecutor: fakeOsExecutor,
+				client:     client,
+			}
+			client.Global = globalAPI
+			isCommandRunCalled := false
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				isCommandRunCalled = true
+				return []byte{}, nil
+			}
+
+			options := DefaultUploadOptions()
+			options.CompressionType = "rar"
+
+			_, err := client.Global.Upload("web", "/tmp/data", "", options)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "unsupported `CompressionType` `rar`")
+			assert.False(t, isCommandRunCalled)
+		},
+	)
+}
+
+func TestGlobalAPI_WinrmConfig(t *testing.T) {
+	t.Run(
+		"with 2 hosts WinRM config returned as `winrm-config` lines, it returns WinrmConfig per host",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+			isCommandRunCalled := false
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{"--machine-readable", "winrm-config"}, args)
+				isCommandRunCalled = true
+
+				output := `
+1547587389,win1,metadata,provider,virtualbox
+1547587390,win1,winrm-config,Host win1\n  HostName 127.0.0.1\n  User vagrant\n  Password vagrant\n  Port 55985\n  RDPHostName 127.0.0.1\n  RDPPort 3389\n  RDPUser vagrant\n  RDPPassword vagrant\n
+1547587390,win2,winrm-config,Host win2\n  HostName 192.168.121.10\n  User Administrator\n  Password s3cr3t\n  Port 5986\n  Transport ssl\n
+`
+				return []byte(output), nil
+			}
+
+			winrmConfigs, err := client.Global.WinrmConfig(DefaultWinrmConfigOptions())
+			require.NoError(t, err)
+			assert.True(t, isCommandRunCalled)
+			require.Len(t, winrmConfigs, 2)
+
+			assert.Equal(t, &WinrmConfig{
+				Host:        "win1",
+				HostName:    "127.0.0.1",
+				Port:        55985,
+				Username:    "vagrant",
+				Password:    "vagrant",
+				Transport:   "",
+				RDPHostName: "127.0.0.1",
+				RDPPort:     3389,
+				RDPUsername: "vagrant",
+				RDPPassword: "vagrant",
+			}, winrmConfigs["win1"])
+
+			assert.Equal(t, &WinrmConfig{
+				Host:      "win2",
+				HostName:  "192.168.121.10",
+				Port:      5986,
+				Username:  "Administrator",
+				Password:  "s3cr3t",
+				Transport: "ssl",
+			}, winrmConfigs["win2"])
+		},
+	)
+
+	t.Run(
+		"with options providing 'Host' and WinRM config returned as `ui` lines, it executes command with '--host' and returns WinrmConfig",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{"--machine-readable", "winrm-config", "--host", "windows"}, args)
+
+				output := `1547587390,,ui,info,Host windows\n  HostName 127.0.0.1\n  User vagrant\n  Password vagrant\n  Port 55985\n`
+				return []byte(output), nil
+			}
+
+			options := DefaultWinrmConfigOptions()
+			options.Host = "windows"
+
+			winrmConfigs, err := client.Global.WinrmConfig(options)
+			require.NoError(t, err)
+			require.Len(t, winrmConfigs, 1)
+
+			assert.Equal(t, "127.0.0.1", winrmConfigs["windows"].HostName)
+			assert.Equal(t, 55985, winrmConfigs["windows"].Port)
+		},
+	)
+
+	t.Run(
+		"with options providing 'Host' and 2 machines, it returns WinrmConfig per machine name",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{"--machine-readable", "winrm-config", "--host", "windows"}, args)
+
+				output := `
+1547587390,win1,winrm-config,Host windows\n  HostName 127.0.0.1\n  User vagrant\n  Password vagrant\n  Port 55985\n
+1547587390,win2,winrm-config,Host windows\n  HostName 127.0.0.1\n  User vagrant\n  Password vagrant\n  Port 55986\n
+`
+				return []byte(output), nil
+			}
+
+			options := DefaultWinrmConfigOptions()
+			options.Host = "windows"
+
+			winrmConfigs, err := client.Global.WinrmConfig(options)
+			require.NoError(t, err)
+			require.Len(t, winrmConfigs, 2)
+
+			assert.Equal(t, "windows", winrmConfigs["win1"].Host)
+			assert.Equal(t, 55985, winrmConfigs["win1"].Port)
+			assert.Equal(t, "windows", winrmConfigs["win2"].Host)
+			assert.Equal(t, 55986, winrmConfigs["win2"].Port)
+		},
+	)
+
+	t.Run(
+		"with invalid port returned, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				output := `1547587390,win1,winrm-config,Host win1\n  Port abc\n`
+				return []byte(output), nil
+			}
+
+			winrmConfigs, err := client.Global.WinrmConfig(DefaultWinrmConfigOptions())
+			assert.Nil(t, winrmConfigs)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid `Port` of host `win1`")
+		},
+	)
+
+	t.Run(
+		"with command execution returning an error, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				return []byte{}, errors.New("fake error")
+			}
+
+			winrmConfigs, err := client.Global.WinrmConfig(DefaultWinrmConfigOptions())
+			assert.Nil(t, winrmConfigs)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "command execution failed")
+		},
+	)
+}
+
+func TestGlobalAPI_SshConnectionInfo(t *testing.T) {
+	t.Run(
+		"with 2 hosts SSH config returned, it returns SshConnectionInfo keyed by machine name",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			isCommandRunCalled := false
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{"--machine-readable", "ssh-config"}, args)
+				isCommandRunCalled = true
+
+				output := `
+1547587389,master,metadata,provider,libvirt
+1547587390,master,ssh-config,Host master\n  HostName 192.168.121.148\n  User vagrant\n  Port 2222\n  UserKnownHostsFile /dev/null\n  StrictHostKeyChecking no\n  PasswordAuthentication no\n  IdentityFile /home/syndbg/.vagrant.d/insecure_private_key\n  IdentityFile /tmp/master/private_key\n  IdentitiesOnly yes\n  LogLevel FATAL\n  ForwardAgent yes\n
+1547587390,node1,ssh-config,Host node1\n  HostName 192.168.121.223\n  User root\n  IdentityFile /tmp/node1/private_key\n  ProxyCommand ssh -W %h:%p bastion\n
+`
+				return []byte(output), nil
+			}
+
+			connectionInfos, err := client.Global.SshConnectionInfo(DefaultSshConfigOptions())
+			require.NoError(t, err)
+			assert.True(t, isCommandRunCalled)
+			require.Len(t, connectionInfos, 2)
+
+			assert.Equal(t, &SshConnectionInfo{
+				Machine:  "master",
+				Host:     "master",
+				HostName: "192.168.121.148",
+				Port:     2222,
+				User:     "vagrant",
+				IdentityFiles: []string{
+					"/home/syndbg/.vagrant.d/insecure_private_key",
+					"/tmp/master/private_key",
+				},
+				StrictHostKeyChecking: "no",
+				ForwardAgent:          true,
+				ProxyCommand:          "",
+			}, connectionInfos["master"])
+
+			assert.Equal(t, &SshConnectionInfo{
+				Machine:               "node1",
+				Host:                  "node1",
+				HostName:              "192.168.121.223",
+				Port:                  22,
+				User:                  "root",
+				IdentityFiles:         []string{"/tmp/node1/private_key"},
+				StrictHostKeyChecking: "",
+				ForwardAgent:          false,
+				ProxyCommand:          "ssh -W %h:%p bastion",
+			}, connectionInfos["node1"])
+		},
+	)
+
+	t.Run(
+		"with options providing 'Name', it keys SshConnectionInfo by machine name rather than host alias",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{"--machine-readable", "ssh-config", "--name", "my-alias"}, args)
+
+				output := `1547587390,default,ssh-config,Host my-alias\n  HostName 192.168.121.148\n  User vagrant\n`
+				return []byte(output), nil
+			}
+
+			options := DefaultSshConfigOptions()
+			options.Name = "my-alias"
+
+			connectionInfos, err := client.Global.SshConnectionInfo(options)
+			require.NoError(t, err)
+			require.Len(t, connectionInfos, 1)
+
+			assert.Equal(t, "default", connectionInfos["default"].Machine)
+			assert.Equal(t, "my-alias", connectionInfos["default"].Host)
+		},
+	)
+
+	t.Run(
+		"with invalid port returned, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				output := `1547587390,default,ssh-config,Host default\n  Port abc\n`
+				return []byte(output), nil
+			}
+
+			connectionInfos, err := client.Global.SshConnectionInfo(DefaultSshConfigOptions())
+			assert.Nil(t, connectionInfos)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid `Port` of machine `default`")
+		},
+	)
+
+	t.Run(
+		"with command execution returning an error, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				return []byte{}, errors.New("fake error")
+			}
+
+			connectionInfos, err := client.Global.SshConnectionInfo(DefaultSshConfigOptions())
+			assert.Nil(t, connectionInfos)
+			assert.Error(t, err, "fake error")
+		},
+	)
+}
+
+func TestGlobalAPI_Status(t *testing.T) {
+	t.Run(
+		"with 2 machines in output, it returns status per machine in order of output",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			isCommandRunCalled := false
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{"--machine-readable", "status"}, args)
+				isCommandRunCalled = true
+
+				output := `
+1546430404,web,metadata,provider,libvirt
+1546430404,db,metadata,provider,libvirt
+1546430404,web,provider-name,libvirt
+1546430404,web,state,running
+1546430404,web,state-human-short,running
+1546430404,web,state-human-long,The Libvirt domain is running. To stop this machine%!(VAGRANT_COMMA) you can run\n'vagrant halt'.
+1546430404,db,provider-name,libvirt
+1546430404,db,state,not_created
+1546430404,db,state-human-short,not created
+1546430404,db,state-human-long,The Libvirt domain is not created. Run 'vagrant up' to create it.
+1546430404,,ui,info,Current machine states:
+`
+				return []byte(output), nil
+			}
+
+			statuses, err := client.Global.Status(DefaultStatusOptions())
+			require.NoError(t, err)
+			assert.True(t, isCommandRunCalled)
+			require.Len(t, statuses, 2)
+
+			assert.Equal(t, &MachineStatus{
+				Name:            "web",
+				Provider:        "libvirt",
+				State:           "running",
+				NormalizedState: MachineStateRunning,
+				StateHumanShort: "running",
+				StateHumanLong:  "The Libvirt domain is running. To stop this machine, you can run\n'vagrant halt'.",
+			}, statuses[0])
+
+			assert.Equal(t, "db", statuses[1].Name)
+			assert.Equal(t, "not_created", statuses[1].State)
+			assert.Equal(t, MachineStateNotCreated, statuses[1].NormalizedState)
+			assert.Equal(t, "not created", statuses[1].StateHumanShort)
+		},
+	)
+
+	t.Run(
+		"with options providing 'Targets', it executes command with targets",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{"--machine-readable", "status", "db", "/web\\d/"}, args)
+				return []byte{}, nil
+			}
+
+			options := DefaultStatusOptions()
+			options.Targets = []string{"db", "/web\\d/"}
+
+			statuses, err := client.Global.Status(options)
+			require.NoError(t, err)
+			assert.Empty(t, statuses)
+		},
+	)
+
+	t.Run(
+		"with command execution returning an error, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				return []byte{}, errors.New("fake error")
+			}
+
+			statuses, err := client.Global.Status(DefaultStatusOptions())
+			assert.Nil(t, statuses)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "command execution failed")
+		},
+	)
+}
+
+func TestGlobalAPI_Halt(t *testing.T) {
+	t.Run(
+		"with default options, it executes command without '--force'",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			isCommandRunCalled := false
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{"--machine-readable", "halt"}, args)
+				isCommandRunCalled = true
+				return []byte{}, nil
+			}
+
+			err := client.Global.Halt(DefaultHaltOptions())
+			require.NoError(t, err)
+			assert.True(t, isCommandRunCalled)
+		},
+	)
+
+	t.Run(
+		"with options providing 'Force' and 'Targets', it executes command with '--force' and targets",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{"--machine-readable", "halt", "--force", "web"}, args)
+				return []byte{}, errors.New("fake error")
+			}
+
+			options := DefaultHaltOptions()
+			options.Force = true
+			options.Targets = []string{"web"}
+
+			err := client.Global.Halt(options)
+			assert.Error(t, err, "fake error")
+		},
+	)
+}
+
+func TestGlobalAPI_Resume(t *testing.T) {
+	t.Parallel()
+
+	client := emptyTestClient(t)
+	isCommandRunCalled := false
+
+	client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+		assert.Equal(t, []string{"--machine-readable", "resume", "web"}, args)
+		isCommandRunCalled = true
+		return []byte{}, nil
+	}
+
+	options := DefaultResumeOptions()
+	options.Targets = []string{"web"}
+
+	err := client.Global.Resume(options)
+	require.NoError(t, err)
+	assert.True(t, isCommandRunCalled)
+}
+
+func TestGlobalAPI_SshExec(t *testing.T) {
+	t.Run(
+		"with machine and command, it executes command without '--machine-readable' and returns raw output",
+		func(t *testing.T) {
+			t.Parallel()
+			fakeOsExecutor := &fakeOsExecutor{}
+
+			fakeCwd := "/tmp/anotherexample"
+			fakeOptionsWd := "/tmp/example"
+			fakeOsExecutor.On("Getwd").Return(fakeCwd, nil)
+			fakeOsExecutor.On("Chdir", fakeOptionsWd).Return(nil)
+			fakeOsExecutor.On("Chdir", fakeCwd).Return(nil)
+
+			client := emptyTestClient(t)
+			globalAPI := &globalAPI{
+				osExecutor: fakeOsExecutor,
+				client:     client,
+			}
+			client.Global = globalAPI
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{"ssh", "--command", "uname -a", "web"}, args)
+				return []byte("Linux web 4.9.0-8-amd64\n"), nil
+			}
+
+			options := DefaultSshExecOptions()
+			options.WorkingDirectory = fakeOptionsWd
+
+			output, err := client.Global.SshExec("web", "uname -a", options)
+			require.NoError(t, err)
+			assert.Equal(t, "Linux web 4.9.0-8-amd64\n", output)
+
+			fakeOsExecutor.AssertCalled(t, "Chdir", fakeCwd)
+		},
+	)
+
+	t.Run(
+		"with command failing on the guest, it returns its output and an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{"ssh", "--command", "false"}, args)
+				return []byte("oops"), errors.New("exit status 1")
+			}
+
+			output, err := client.Global.SshExec("", "false", DefaultSshExecOptions())
+			assert.Error(t, err, "exit status 1")
+			assert.Equal(t, "oops", output)
+		},
+	)
+}
+
+func TestGlobalAPI_Reload(t *testing.T) {
+	t.Run(
+		"with default options, it executes command with '--no-provision' and returns result per machine",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{"--machine-readable", "reload", "--no-provision"}, args)
+
+				output := `
+1546430400,default,metadata,provider,libvirt
+1546430400,default,action,reload,start
+1546430430,default,action,reload,end
+`
+				return []byte(output), nil
+			}
+
+			result, err := client.Global.Reload(DefaultReloadOptions())
+			require.NoError(t, err)
+			require.Len(t, result.Machines, 1)
+			assert.Equal(t, MachineOutcomeSucceeded, result.Machines[0].Outcome)
+			require.Len(t, result.Timeline.Machines, 1)
+			assert.Equal(t, "default", result.Timeline.Machines[0].Name)
+		},
+	)
+
+	t.Run(
+		"with options providing 'Provision', 'ProvisionWith' and 'Targets', it executes command with them",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(
+					t,
+					[]string{"--machine-readable", "reload", "--provision", "--provision-with", "shell,file", "web"},
+					args,
+				)
+				return []byte{}, nil
+			}
+
+			options := DefaultReloadOptions()
+			options.Provision = true
+			options.ProvisionWith = []string{"shell", "file"}
+			options.Targets = []string{"web"}
+
+			_, err := client.Global.Reload(options)
+			require.NoError(t, err)
+		},
+	)
+}
+
+func TestGlobalAPI_Provision(t *testing.T) {
+	t.Run(
+		"with options providing 'ProvisionWith' and 'Targets', it executes command with them",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{"--machine-readable", "provision", "--provision-with", "shell", "web"}, args)
+
+				output := `
+1546430400,web,metadata,provider,libvirt
+1546430400,web,action,provision,start
+1546430400,web,ui,info,Running provisioner: shell...
+`
+				return []byte(output), errors.New("fake error")
+			}
+
+			options := DefaultProvisionOptions()
+			options.ProvisionWith = []string{"shell"}
+			options.Targets = []string{"web"}
+
+			result, err := client.Global.Provision(options)
+			assert.Error(t, err)
+			require.Len(t, result.Machines, 1)
+			assert.Equal(t, MachineOutcomeFailed, result.Machines[0].Outcome)
+			assert.Equal(t, []string{"shell"}, result.Machines[0].Provisioners)
+		},
+	)
+}
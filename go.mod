@@ -1,5 +1,7 @@
 module github.com/syndbg/vagrant-go
 
+go 1.16
+
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/kevinburke/ssh_config v0.0.0-20180830205328-81db2a75821e
@@ -8,4 +10,5 @@ require (
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/stretchr/objx v0.1.1 // indirect
 	github.com/stretchr/testify v1.2.2
+	golang.org/x/sys v0.0.0-20210423082822-04245dca01da
 )
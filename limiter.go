@@ -0,0 +1,322 @@
+package vagrant_go
+
+import (
+	"errors"
+	"fmt"
+	"github.com/palantir/stacktrace"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+const defaultLimiterDirectoryName = "vagrant-go-limiter"
+
+// ConcurrencyLimitOptions configure a limit of `Up` and `Reload` operations running at a time on the host, shared by
+// all processes using the same lock directory. Waiting operations are queued and run in order of arrival.
+type ConcurrencyLimitOptions struct {
+	// MaxConcurrent is the maximum number of operations running at a time. It must be positive.
+	MaxConcurrent int
+	// Timeout is the maximum time to wait in the queue. `ErrorCodeConcurrencyLimitTimeout` is returned after it.
+	Timeout time.Duration
+	// PollInterval is the time between checks of the queue.
+	PollInterval time.Duration
+	// Directory is the lock directory. It's `vagrant-go-limiter` in `VAGRANT_HOME`, if empty. A relative directory is
+	// resolved from the working directory of the operation, like a relative `VAGRANT_HOME` is by Vagrant.
+	Directory string
+}
+
+func DefaultConcurrencyLimitOptions() *ConcurrencyLimitOptions {
+	return &ConcurrencyLimitOptions{
+		MaxConcurrent: 2,
+		Timeout:       30 * time.Minute,
+		PollInterval:  time.Second,
+		Directory:     "",
+	}
+}
+
+func (o *ConcurrencyLimitOptions) validate() error {
+	if o.MaxConcurrent <= 0 {
+		return stacktrace.NewError("`MaxConcurrent` of concurrency limit must be positive, got %d", o.MaxConcurrent)
+	}
+
+	return nil
+}
+
+// NOTE: Ticket names sort in order of arrival, also for tickets created by a process within the same nanosecond.
+var ticketCounter uint64
+
+// withConcurrencyLimit calls `fn` holding a slot of `Config.ConcurrencyLimit`, if set, or just calls it otherwise.
+// It returns the time spent waiting for the slot.
+func (c *Client) withConcurrencyLimit(fn func() error) (time.Duration, error) {
+	if c.Config.ConcurrencyLimit == nil {
+		return 0, fn()
+	}
+
+	err := c.Config.ConcurrencyLimit.validate()
+	if err != nil {
+		return 0, err
+	}
+
+	directory, err := limiterDirectory(c.osExecutor, c.Config.ConcurrencyLimit)
+	if err != nil {
+		return 0, err
+	}
+
+	slot, wait, err := acquireSlot(directory, c.Config.ConcurrencyLimit)
+	if err != nil {
+		return wait, err
+	}
+
+	err = fn()
+
+	releaseErr := slot.release()
+	if err != nil {
+		return wait, err
+	}
+
+	return wait, releaseErr
+}
+
+// acquireSlot waits in the queue for a free slot and takes it. Each waiter holds a lock on its own ticket in the
+// queue, so that tickets of crashed processes are detected and removed. Only the waiter with the oldest ticket takes
+// a slot, which keeps the queue fair.
+func acquireSlot(directory string, options *ConcurrencyLimitOptions) (*fileLock, time.Duration, error) {
+	startedAt := time.Now()
+
+	queueDirectory := filepath.Join(directory, "queue")
+
+	ticket, ticketPath, err := createTicket(queueDirectory)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// NOTE: The ticket is released before it's removed, since open files can't be removed on Windows
+	defer func() {
+		_ = ticket.release()
+		_ = os.Remove(ticketPath)
+	}()
+
+	deadline := startedAt.Add(options.Timeout)
+
+	for {
+		isFirst, err := isFirstInQueue(queueDirectory, filepath.Base(ticketPath))
+		if err != nil {
+			return nil, time.Since(startedAt), err
+		}
+
+		if isFirst {
+			for i := 0; i < options.MaxConcurrent; i++ {
+				slot, taken, err := tryAcquireFileLock(filepath.Join(directory, fmt.Sprintf("slot-%d.lock", i)))
+				if err != nil {
+					return nil, time.Since(startedAt), err
+				}
+
+				if taken {
+					return slot, time.Since(startedAt), nil
+				}
+			}
+		}
+
+		if !time.Now().Before(deadline) {
+			return nil, time.Since(startedAt), stacktrace.NewErrorWithCode(
+				ErrorCodeConcurrencyLimitTimeout,
+				"timed out after %s waiting for one of %d slots in `%s`",
+				options.Timeout,
+				options.MaxConcurrent,
+				directory,
+			)
+		}
+
+		time.Sleep(options.PollInterval)
+	}
+}
+
+func limiterDirectory(osExecutor OsExecutor, options *ConcurrencyLimitOptions) (string, error) {
+	if len(options.Directory) > 0 {
+		return options.Directory, nil
+	}
+
+	home, err := vagrantHome(osExecutor)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, defaultLimiterDirectoryName), nil
+}
+
+// createTicket creates a locked ticket at the end of the queue and returns it with its path. It's created under its
+// final name and locked right away, since open files can't be renamed on Windows. A waiter, that checks the ticket
+// before it's locked, takes it for a ticket of a crashed process and removes it, so it's created again then.
+func createTicket(queueDirectory string) (*fileLock, string, error) {
+	err := os.MkdirAll(queueDirectory, 0o755)
+	if err != nil {
+		return nil, "", stacktrace.Propagate(err, "failed to create queue `%s`", queueDirectory)
+	}
+
+	for {
+		path := filepath.Join(
+			queueDirectory,
+			fmt.Sprintf(
+				"%020d-%010d-%010d",
+				time.Now().UnixNano(),
+				os.Getpid(),
+				atomic.AddUint64(&ticketCounter, 1),
+			),
+		)
+
+		ticket, isQueued, err := tryCreateTicket(path)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if isQueued {
+			return ticket, path, nil
+		}
+	}
+}
+
+// tryCreateTicket creates and locks the ticket at `path`. It returns false, if the ticket was taken for a ticket of a
+// crashed process in the meantime.
+func tryCreateTicket(path string) (*fileLock, bool, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, false, stacktrace.Propagate(err, "failed to create ticket `%s`", path)
+	}
+
+	err = tryLockFile(file)
+	if errors.Is(err, errLockHeld) {
+		_ = file.Close()
+		return nil, false, nil
+	}
+
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(path)
+
+		return nil, false, stacktrace.Propagate(err, "failed to lock ticket `%s`", path)
+	}
+
+	ticket := &fileLock{file: file}
+
+	isQueued, err := isSameFile(file, path)
+	if err != nil || !isQueued {
+		_ = ticket.release()
+		return nil, false, err
+	}
+
+	return ticket, true, nil
+}
+
+// isSameFile returns whether `file` is still the file at `path`.
+func isSameFile(file *os.File, path string) (bool, error) {
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return false, stacktrace.Propagate(err, "failed to check ticket `%s`", path)
+	}
+
+	pathInfo, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, stacktrace.Propagate(err, "failed to check ticket `%s`", path)
+	}
+
+	return os.SameFile(fileInfo, pathInfo), nil
+}
+
+// isFirstInQueue returns whether `ticketName` is the oldest ticket in the queue. Older tickets, that are not locked,
+// belong to crashed processes and are removed.
+func isFirstInQueue(queueDirectory string, ticketName string) (bool, error) {
+	entries, err := ioutil.ReadDir(queueDirectory)
+	if err != nil {
+		return false, stacktrace.Propagate(err, "failed to read queue `%s`", queueDirectory)
+	}
+
+	//noinspection GoPreferNilSlice
+	names := []string{}
+
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if name >= ticketName {
+			return true, nil
+		}
+
+		path := filepath.Join(queueDirectory, name)
+
+		isStale, err := removeStaleTicket(path)
+		if err != nil {
+			return false, err
+		}
+
+		if !isStale {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// removeStaleTicket removes the ticket at `path`, if it's not locked. Tickets, that don't exist anymore, are stale.
+func removeStaleTicket(path string) (bool, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+
+	if err != nil {
+		return false, stacktrace.Propagate(err, "failed to open ticket `%s`", path)
+	}
+
+	err = tryLockFile(file)
+	if errors.Is(err, errLockHeld) {
+		_ = file.Close()
+		return false, nil
+	}
+
+	if err != nil {
+		_ = file.Close()
+		return false, stacktrace.Propagate(err, "failed to check ticket `%s`", path)
+	}
+
+	// NOTE: The ticket is released before it's removed, since open files can't be removed on Windows
+	err = (&fileLock{file: file}).release()
+	if err != nil {
+		return false, err
+	}
+
+	// NOTE: On Windows, a ticket, that's just created and not locked yet, can't be removed, so it's live
+	err = os.Remove(path)
+	return err == nil || os.IsNotExist(err), nil
+}
+
+// tryAcquireFileLock takes an exclusive advisory lock on file `path`, creating it if missing, without waiting.
+// It returns false, if the lock is held by another process or handle.
+func tryAcquireFileLock(path string) (*fileLock, bool, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, false, stacktrace.Propagate(err, "failed to open lock `%s`", path)
+	}
+
+	err = tryLockFile(file)
+	if errors.Is(err, errLockHeld) {
+		_ = file.Close()
+		return nil, false, nil
+	}
+
+	if err != nil {
+		_ = file.Close()
+		return nil, false, stacktrace.Propagate(err, "failed to take lock `%s`", path)
+	}
+
+	return &fileLock{file: file}, true, nil
+}
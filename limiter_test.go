@@ -0,0 +1,341 @@
+package vagrant_go
+
+import (
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testConcurrencyLimitOptions(t *testing.T, maxConcurrent int) *ConcurrencyLimitOptions {
+	return &ConcurrencyLimitOptions{
+		MaxConcurrent: maxConcurrent,
+		Timeout:       5 * time.Second,
+		PollInterval:  5 * time.Millisecond,
+		Directory:     t.TempDir(),
+	}
+}
+
+func queuedTickets(t *testing.T, options *ConcurrencyLimitOptions) int {
+	entries, err := ioutil.ReadDir(filepath.Join(options.Directory, "queue"))
+	require.NoError(t, err)
+
+	return len(entries)
+}
+
+func waitForQueuedTickets(t *testing.T, options *ConcurrencyLimitOptions, count int) {
+	deadline := time.Now().Add(time.Second)
+
+	for queuedTickets(t, options) != count {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d queued tickets", count)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAcquireSlot(t *testing.T) {
+	t.Run(
+		"with all slots taken, it times out with error code",
+		func(t *testing.T) {
+			t.Parallel()
+
+			options := testConcurrencyLimitOptions(t, 1)
+
+			slot, _, err := acquireSlot(options.Directory, options)
+			require.NoError(t, err)
+
+			options.Timeout = 50 * time.Millisecond
+
+			_, wait, err := acquireSlot(options.Directory, options)
+			require.Error(t, err)
+			assert.Equal(t, ErrorCodeConcurrencyLimitTimeout, stacktrace.GetCode(err))
+			assert.True(t, wait >= 50*time.Millisecond)
+			assert.Equal(t, 0, queuedTickets(t, options))
+
+			require.NoError(t, slot.release())
+		},
+	)
+
+	t.Run(
+		"with 2 slots, it gives both without waiting",
+		func(t *testing.T) {
+			t.Parallel()
+
+			options := testConcurrencyLimitOptions(t, 2)
+
+			first, _, err := acquireSlot(options.Directory, options)
+			require.NoError(t, err)
+
+			second, _, err := acquireSlot(options.Directory, options)
+			require.NoError(t, err)
+
+			require.NoError(t, first.release())
+			require.NoError(t, second.release())
+		},
+	)
+
+	t.Run(
+		"with ticket of a crashed process in the queue, it removes it and takes a slot",
+		func(t *testing.T) {
+			t.Parallel()
+
+			options := testConcurrencyLimitOptions(t, 1)
+
+			staleTicketPath := filepath.Join(options.Directory, "queue", "00000000000000000000-0000000001-0000000001")
+			require.NoError(t, os.MkdirAll(filepath.Dir(staleTicketPath), 0o755))
+			require.NoError(t, os.WriteFile(staleTicketPath, nil, 0o644))
+
+			slot, _, err := acquireSlot(options.Directory, options)
+			require.NoError(t, err)
+			require.NoError(t, slot.release())
+
+			_, err = os.Stat(staleTicketPath)
+			assert.True(t, os.IsNotExist(err))
+		},
+	)
+
+	t.Run(
+		"with waiters queued, it gives the freed slot in order of arrival",
+		func(t *testing.T) {
+			t.Parallel()
+
+			options := testConcurrencyLimitOptions(t, 1)
+
+			slot, _, err := acquireSlot(options.Directory, options)
+			require.NoError(t, err)
+
+			var mutex sync.Mutex
+			order := []string{}
+
+			var wg sync.WaitGroup
+			wait := func(name string) {
+				defer wg.Done()
+
+				waiterSlot, wait, err := acquireSlot(options.Directory, options)
+				require.NoError(t, err)
+				assert.True(t, wait > 0)
+
+				mutex.Lock()
+				order = append(order, name)
+				mutex.Unlock()
+
+				time.Sleep(10 * time.Millisecond)
+				require.NoError(t, waiterSlot.release())
+			}
+
+			wg.Add(2)
+			go wait("first")
+			waitForQueuedTickets(t, options, 1)
+			go wait("second")
+			waitForQueuedTickets(t, options, 2)
+
+			require.NoError(t, slot.release())
+			wg.Wait()
+
+			assert.Equal(t, []string{"first", "second"}, order)
+		},
+	)
+}
+
+func TestClient_ConcurrencyLimit(t *testing.T) {
+	t.Run(
+		"with concurrency limit of 1, it runs `Up` operations one at a time and reports wait time",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+			client.Config.ConcurrencyLimit = testConcurrencyLimitOptions(t, 1)
+
+			var mutex sync.Mutex
+			running, maxRunning := 0, 0
+
+			client.commandRunFunc = func(cmd string, args ...string) ([]byte, error) {
+				mutex.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mutex.Unlock()
+
+				time.Sleep(20 * time.Millisecond)
+
+				mutex.Lock()
+				running--
+				mutex.Unlock()
+
+				return []byte{}, nil
+			}
+
+			var wg sync.WaitGroup
+			queueWaits := make([]time.Duration, 3)
+
+			for i := range queueWaits {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+
+					result, err := client.Global.Up(DefaultUpOptions())
+					require.NoError(t, err)
+
+					queueWaits[i] = result.QueueWait
+				}(i)
+			}
+			wg.Wait()
+
+			assert.Equal(t, 1, maxRunning)
+
+			var totalWait time.Duration
+			for _, queueWait := range queueWaits {
+				totalWait += queueWait
+			}
+			assert.True(t, totalWait >= 20*time.Millisecond)
+		},
+	)
+
+	t.Run(
+		"with no concurrency limit, it reports no wait time",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := emptyTestClient(t)
+
+			result, err := client.Global.Reload(DefaultReloadOptions())
+			require.NoError(t, err)
+			assert.Equal(t, time.Duration(0), result.QueueWait)
+		},
+	)
+
+	t.Run(
+		"with non-positive 'MaxConcurrent', NewClient returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client, err := NewClient(
+				&Config{ConcurrencyLimit: testConcurrencyLimitOptions(t, 0)},
+				emptyCommandRunFunc,
+				emptyLookPathFunc,
+			)
+			assert.Nil(t, client)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "`MaxConcurrent` of concurrency limit must be positive")
+		},
+	)
+
+	t.Run(
+		"with all slots taken until the timeout, `Up` returns the wait time and an error with code",
+		func(t *testing.T) {
+			t.Parallel()
+
+			options := testConcurrencyLimitOptions(t, 1)
+			options.Timeout = 20 * time.Millisecond
+
+			slot, _, err := acquireSlot(options.Directory, options)
+			require.NoError(t, err)
+			defer func() {
+				_ = slot.release()
+			}()
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+			client.Config.ConcurrencyLimit = options
+			client.commandRunFunc = func(cmd string, args ...string) ([]byte, error) {
+				t.Fatalf("unexpected execution of `%s %v`", cmd, args)
+				return nil, nil
+			}
+
+			result, err := client.Global.Up(DefaultUpOptions())
+			require.Error(t, err)
+			assert.Equal(t, ErrorCodeConcurrencyLimitTimeout, stacktrace.GetCode(err))
+
+			require.NotNil(t, result)
+			assert.Empty(t, result.Machines)
+			assert.True(t, result.QueueWait >= options.Timeout)
+		},
+	)
+
+	t.Run(
+		"with `Up` retried, it releases the slot during backoff",
+		func(t *testing.T) {
+			t.Parallel()
+
+			options := testConcurrencyLimitOptions(t, 1)
+
+			client, calls, _ := retryTestClient(t, []string{machineLockedOutput, ""})
+			client.version = testVersion
+			client.Config.ConcurrencyLimit = options
+			client.sleepFunc = func(d time.Duration) {
+				backoffOptions := testConcurrencyLimitOptions(t, 1)
+				backoffOptions.Directory = options.Directory
+				backoffOptions.Timeout = 20 * time.Millisecond
+
+				slot, _, err := acquireSlot(options.Directory, backoffOptions)
+				require.NoError(t, err)
+				require.NoError(t, slot.release())
+			}
+
+			upOptions := DefaultUpOptions()
+			upOptions.Retry = true
+
+			_, err := client.Global.Up(upOptions)
+			require.NoError(t, err)
+			assert.Equal(t, 2, *calls)
+		},
+	)
+
+	t.Run(
+		"with lock options and project lock held, `Up` doesn't hold a slot waiting for the project",
+		func(t *testing.T) {
+			t.Parallel()
+
+			directory := t.TempDir()
+
+			lockPath, err := projectLockPath(directory)
+			require.NoError(t, err)
+
+			lock, err := acquireFileLock(lockPath, testLockOptions())
+			require.NoError(t, err)
+			defer func() {
+				_ = lock.release()
+			}()
+
+			options := testConcurrencyLimitOptions(t, 1)
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+			client.Config.Lock = testLockOptions()
+			client.Config.ConcurrencyLimit = options
+
+			fakeOsExecutor := &fakeOsExecutor{}
+			fakeOsExecutor.On("Getwd").Return(directory, nil)
+			fakeOsExecutor.On("Chdir", directory).Return(nil)
+
+			api := &globalAPI{client: client, osExecutor: fakeOsExecutor}
+
+			upErr := make(chan error)
+			go func() {
+				_, err := api.Up(DefaultUpOptions())
+				upErr <- err
+			}()
+
+			time.Sleep(20 * time.Millisecond)
+
+			slotOptions := testConcurrencyLimitOptions(t, 1)
+			slotOptions.Directory = options.Directory
+			slotOptions.Timeout = 20 * time.Millisecond
+
+			slot, _, err := acquireSlot(options.Directory, slotOptions)
+			require.NoError(t, err)
+			require.NoError(t, slot.release())
+
+			assert.Equal(t, ErrorCodeLockTimeout, stacktrace.GetCode(<-upErr))
+		},
+	)
+}
@@ -0,0 +1,205 @@
+package vagrant_go
+
+import (
+	"errors"
+	"github.com/palantir/stacktrace"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const lockFileName = "vagrant-go.lock"
+
+// errLockHeld is returned by `tryLockFile`, when the lock is held by another process or handle.
+var errLockHeld = errors.New("lock is held")
+
+// LockOptions configure advisory file locks, that are taken per project, that is the directory of its Vagrantfile,
+// before executing commands, and per `VAGRANT_HOME` before executing box commands. Commands of concurrent processes wait for each other, instead
+// of failing on Vagrant's own machine locks. Locks are taken with `flock` on Unix and `LockFileEx` on Windows.
+type LockOptions struct {
+	// Timeout is the maximum time to wait for a lock. `ErrorCodeLockTimeout` is returned after it.
+	Timeout time.Duration
+	// PollInterval is the time between attempts to take a lock held by another process.
+	PollInterval time.Duration
+}
+
+func DefaultLockOptions() *LockOptions {
+	return &LockOptions{
+		Timeout:      10 * time.Minute,
+		PollInterval: time.Second,
+	}
+}
+
+type fileLock struct {
+	file *os.File
+}
+
+// acquireFileLock takes an exclusive advisory lock on file `path`, creating it and its directory if missing.
+func acquireFileLock(path string, options *LockOptions) (*fileLock, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to create directory of lock `%s`", path)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to open lock `%s`", path)
+	}
+
+	deadline := time.Now().Add(options.Timeout)
+
+	for {
+		err = tryLockFile(file)
+		if err == nil {
+			return &fileLock{file: file}, nil
+		}
+
+		if !errors.Is(err, errLockHeld) {
+			_ = file.Close()
+			return nil, stacktrace.Propagate(err, "failed to take lock `%s`", path)
+		}
+
+		if !time.Now().Before(deadline) {
+			_ = file.Close()
+			return nil, stacktrace.NewErrorWithCode(
+				ErrorCodeLockTimeout,
+				"timed out after %s waiting for lock `%s`",
+				options.Timeout,
+				path,
+			)
+		}
+
+		time.Sleep(options.PollInterval)
+	}
+}
+
+func (l *fileLock) release() error {
+	err := unlockFile(l.file)
+
+	closeErr := l.file.Close()
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to release lock `%s`", l.file.Name())
+	}
+
+	return closeErr
+}
+
+// withLock calls `fn` holding the lock at `path`, when `Config.Lock` is set, or just calls it otherwise.
+func (c *Client) withLock(path string, fn func() error) error {
+	if c.Config.Lock == nil {
+		return fn()
+	}
+
+	lock, err := acquireFileLock(path, c.Config.Lock)
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+
+	releaseErr := lock.release()
+	if err != nil {
+		return err
+	}
+
+	return releaseErr
+}
+
+// inProjectDirectory calls `fn` from within `workingDirectory`, holding the lock of the project if `Config.Lock` is set.
+func (c *Client) inProjectDirectory(osExecutor OsExecutor, workingDirectory string, fn func() error) error {
+	if c.Config.Lock == nil {
+		return inWorkingDirectory(osExecutor, workingDirectory, fn)
+	}
+
+	projectDirectory, err := resolveWorkingDirectory(osExecutor, workingDirectory)
+	if err != nil {
+		return err
+	}
+
+	lockPath, err := projectLockPath(projectDirectory)
+	if err != nil {
+		return err
+	}
+
+	return c.withLock(lockPath, func() error {
+		return inWorkingDirectory(osExecutor, workingDirectory, fn)
+	})
+}
+
+// withVagrantHomeLock calls `fn` holding the lock of `VAGRANT_HOME`, if `Config.Lock` is set.
+func (c *Client) withVagrantHomeLock(fn func() error) error {
+	if c.Config.Lock == nil {
+		return fn()
+	}
+
+	path, err := vagrantHomeLockPath(c.osExecutor)
+	if err != nil {
+		return err
+	}
+
+	return c.withLock(path, fn)
+}
+
+// NOTE: Vagrant looks up the Vagrantfile in these names.
+var vagrantfileNames = []string{"Vagrantfile", "vagrantfile"}
+
+// projectLockPath returns the path of the lock of the project `directory` belongs to. That's the closest directory
+// containing a Vagrantfile, like Vagrant looks it up, or `directory` itself when there's none. Symlinks are resolved,
+// so that all paths of the same project take the same lock.
+// NOTE: The lock is kept in the `.vagrant` directory, that Vagrant keeps the state of the project in.
+func projectLockPath(directory string) (string, error) {
+	resolvedDirectory, err := filepath.EvalSymlinks(directory)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "failed to resolve project directory `%s`", directory)
+	}
+
+	projectDirectory := resolvedDirectory
+
+	for current := resolvedDirectory; ; current = filepath.Dir(current) {
+		if hasVagrantfile(current) {
+			projectDirectory = current
+			break
+		}
+
+		if filepath.Dir(current) == current {
+			break
+		}
+	}
+
+	return filepath.Join(projectDirectory, ".vagrant", lockFileName), nil
+}
+
+func hasVagrantfile(directory string) bool {
+	for _, name := range vagrantfileNames {
+		_, err := os.Stat(filepath.Join(directory, name))
+		if err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func vagrantHomeLockPath(osExecutor OsExecutor) (string, error) {
+	home, err := vagrantHome(osExecutor)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, lockFileName), nil
+}
+
+// vagrantHome returns the directory Vagrant keeps boxes and global state in, that is `VAGRANT_HOME` or `~/.vagrant.d`.
+func vagrantHome(osExecutor OsExecutor) (string, error) {
+	home := osExecutor.Getenv("VAGRANT_HOME")
+	if len(home) > 0 {
+		return home, nil
+	}
+
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return "", stacktrace.Propagate(err, "failed to get `VAGRANT_HOME`")
+	}
+
+	return filepath.Join(userHome, ".vagrant.d"), nil
+}
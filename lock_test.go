@@ -0,0 +1,233 @@
+package vagrant_go
+
+import (
+	"context"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testLockOptions() *LockOptions {
+	return &LockOptions{
+		Timeout:      100 * time.Millisecond,
+		PollInterval: 10 * time.Millisecond,
+	}
+}
+
+func TestAcquireFileLock(t *testing.T) {
+	t.Run(
+		"with lock held, it times out with error code",
+		func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), ".vagrant", lockFileName)
+
+			lock, err := acquireFileLock(path, testLockOptions())
+			require.NoError(t, err)
+
+			_, err = acquireFileLock(path, testLockOptions())
+			require.Error(t, err)
+			assert.Equal(t, ErrorCodeLockTimeout, stacktrace.GetCode(err))
+
+			require.NoError(t, lock.release())
+		},
+	)
+
+	t.Run(
+		"with lock released before the timeout, it takes the lock",
+		func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), lockFileName)
+
+			lock, err := acquireFileLock(path, testLockOptions())
+			require.NoError(t, err)
+
+			go func() {
+				time.Sleep(20 * time.Millisecond)
+				_ = lock.release()
+			}()
+
+			options := testLockOptions()
+			options.Timeout = 5 * time.Second
+
+			secondLock, err := acquireFileLock(path, options)
+			require.NoError(t, err)
+			require.NoError(t, secondLock.release())
+		},
+	)
+}
+
+func TestClient_Lock(t *testing.T) {
+	t.Run(
+		"with lock options, it executes commands of the same project one at a time",
+		func(t *testing.T) {
+			t.Parallel()
+
+			directory := t.TempDir()
+
+			client := emptyTestClient(t)
+			client.Config.Lock = testLockOptions()
+			client.Config.Lock.Timeout = 5 * time.Second
+
+			var mutex sync.Mutex
+			running, maxRunning := 0, 0
+
+			client.commandRunFunc = func(cmd string, args ...string) ([]byte, error) {
+				mutex.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mutex.Unlock()
+
+				time.Sleep(20 * time.Millisecond)
+
+				mutex.Lock()
+				running--
+				mutex.Unlock()
+
+				return []byte{}, nil
+			}
+
+			fakeOsExecutor := &fakeOsExecutor{}
+			fakeOsExecutor.On("Getwd").Return(directory, nil)
+			fakeOsExecutor.On("Chdir", directory).Return(nil)
+
+			api := &globalAPI{client: client, osExecutor: fakeOsExecutor}
+
+			var wg sync.WaitGroup
+			for i := 0; i < 3; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					assert.NoError(t, api.Halt(DefaultHaltOptions()))
+				}()
+			}
+			wg.Wait()
+
+			assert.Equal(t, 1, maxRunning)
+			assert.FileExists(t, filepath.Join(directory, ".vagrant", lockFileName))
+		},
+	)
+
+	t.Run(
+		"with lock options and project lock held, it times out without executing the command",
+		func(t *testing.T) {
+			t.Parallel()
+
+			directory := t.TempDir()
+
+			lockPath, err := projectLockPath(directory)
+			require.NoError(t, err)
+
+			lock, err := acquireFileLock(lockPath, testLockOptions())
+			require.NoError(t, err)
+			defer func() {
+				_ = lock.release()
+			}()
+
+			client := emptyTestClient(t)
+			client.Config.Lock = testLockOptions()
+			client.commandRunFunc = func(cmd string, args ...string) ([]byte, error) {
+				t.Fatalf("unexpected execution of `%s %v`", cmd, args)
+				return nil, nil
+			}
+
+			options := DefaultStatusOptions()
+			options.WorkingDirectory = directory
+
+			_, err = client.Global.Status(options)
+			require.Error(t, err)
+			assert.Equal(t, ErrorCodeLockTimeout, stacktrace.GetCode(err))
+		},
+	)
+}
+
+func TestClient_LockBox(t *testing.T) {
+	t.Parallel()
+
+	vagrantHomeDirectory := t.TempDir()
+
+	fakeOsExecutor := &fakeOsExecutor{}
+	fakeOsExecutor.On("Getenv", "VAGRANT_HOME").Return(vagrantHomeDirectory)
+
+	client := emptyTestClient(t)
+	client.osExecutor = fakeOsExecutor
+	client.Config.Lock = DefaultLockOptions()
+
+	_, err := client.Box.List()
+	require.NoError(t, err)
+
+	_, err = os.Stat(filepath.Join(vagrantHomeDirectory, lockFileName))
+	assert.NoError(t, err)
+}
+
+func TestProjectLockPath(t *testing.T) {
+	t.Run(
+		"with subdirectory of a project reached through a symlink, it returns the lock of the project",
+		func(t *testing.T) {
+			t.Parallel()
+
+			projectDirectory, err := filepath.EvalSymlinks(t.TempDir())
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(filepath.Join(projectDirectory, "Vagrantfile"), nil, 0o644))
+			require.NoError(t, os.Mkdir(filepath.Join(projectDirectory, "provisioning"), 0o755))
+
+			link := filepath.Join(t.TempDir(), "provisioning")
+			require.NoError(t, os.Symlink(filepath.Join(projectDirectory, "provisioning"), link))
+
+			path, err := projectLockPath(link)
+			require.NoError(t, err)
+			assert.Equal(t, filepath.Join(projectDirectory, ".vagrant", lockFileName), path)
+		},
+	)
+
+	t.Run(
+		"with missing directory, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			_, err := projectLockPath(filepath.Join(t.TempDir(), "missing"))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "failed to resolve project directory")
+		},
+	)
+}
+
+func TestClient_LockExecBox(t *testing.T) {
+	t.Parallel()
+
+	vagrantHomeDirectory := t.TempDir()
+	projectDirectory := t.TempDir()
+
+	lock, err := acquireFileLock(filepath.Join(vagrantHomeDirectory, lockFileName), testLockOptions())
+	require.NoError(t, err)
+	defer func() {
+		_ = lock.release()
+	}()
+
+	fakeOsExecutor := &fakeOsExecutor{}
+	fakeOsExecutor.On("Getenv", "VAGRANT_HOME").Return(vagrantHomeDirectory)
+	fakeOsExecutor.On("Getwd").Return(projectDirectory, nil)
+
+	client := emptyTestClient(t)
+	client.osExecutor = fakeOsExecutor
+	client.Config.Lock = testLockOptions()
+	client.commandRunFunc = func(cmd string, args ...string) ([]byte, error) {
+		t.Fatalf("unexpected execution of `%s %v`", cmd, args)
+		return nil, nil
+	}
+
+	_, err = client.Exec(context.Background(), []string{"box", "add", "ubuntu/bionic64"}, DefaultExecOptions())
+	require.Error(t, err)
+	assert.Equal(t, ErrorCodeLockTimeout, stacktrace.GetCode(err))
+
+	_, err = os.Stat(filepath.Join(projectDirectory, ".vagrant"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,94 @@
+package vagrant_go
+
+import (
+	"github.com/palantir/stacktrace"
+	"io"
+)
+
+// OutputLineScanner reads decoded lines of captured machine readable output. Lines that are not machine readable
+// are skipped. Use it like `bufio.Scanner`:
+//
+//	scanner := ParseMachineReadable(reader)
+//	for scanner.Scan() {
+//		line := scanner.Line()
+//	}
+//	err := scanner.Err()
+type OutputLineScanner struct {
+	scanner *vagrantOutputScanner
+}
+
+// ParseMachineReadable returns a scanner over the output of a vagrant command executed with `--machine-readable`,
+// read from `reader`. No `vagrant` binary is needed.
+func ParseMachineReadable(reader io.Reader) *OutputLineScanner {
+	return &OutputLineScanner{
+		scanner: newVagrantOutputScanner(reader, false),
+	}
+}
+
+// Scan advances to the next machine readable line. It returns false when there are no more lines or reading failed.
+func (s *OutputLineScanner) Scan() bool {
+	return s.scanner.Scan()
+}
+
+// Line returns the line read by the last call of `Scan`.
+func (s *OutputLineScanner) Line() *OutputLine {
+	line := s.scanner.Line()
+	if line == nil {
+		return nil
+	}
+
+	return newOutputLine(line)
+}
+
+// Err returns the first error that occurred while reading.
+func (s *OutputLineScanner) Err() error {
+	err := s.scanner.Err()
+	if err != nil {
+		return stacktrace.Propagate(err, "failed to read machine readable output")
+	}
+
+	return nil
+}
+
+func readAllOutputLines(reader io.Reader) ([]*vagrantOutputLine, error) {
+	outputLines, err := scanOutputLines(reader, false)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to read machine readable output")
+	}
+
+	return outputLines, nil
+}
+
+// BoxesFromMachineReadable returns the boxes `Box.List` would return for captured output of `vagrant box list`.
+func BoxesFromMachineReadable(reader io.Reader) ([]*Box, error) {
+	outputLines, err := readAllOutputLines(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return boxesFromOutputLines(outputLines), nil
+}
+
+// StatusesFromMachineReadable returns the statuses `Global.Status` would return for captured output of
+// `vagrant status`.
+func StatusesFromMachineReadable(reader io.Reader) ([]*MachineStatus, error) {
+	outputLines, err := readAllOutputLines(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return machineStatusesFromOutputLines(outputLines), nil
+}
+
+// UpResultFromMachineReadable returns the result `Global.Up` would return for captured output of `vagrant up`.
+func UpResultFromMachineReadable(reader io.Reader) (*UpResult, error) {
+	outputLines, err := readAllOutputLines(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UpResult{
+		Machines: machineResultsFromOutputLines(outputLines, "up", "running"),
+		Timeline: timelineFromOutputLines(outputLines, "up"),
+	}, nil
+}
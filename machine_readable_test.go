@@ -0,0 +1,144 @@
+package vagrant_go
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestParseMachineReadable(t *testing.T) {
+	t.Run(
+		"with captured output mixed with non-machine readable lines, it yields only decoded machine readable lines",
+		func(t *testing.T) {
+			t.Parallel()
+
+			output := `
+Some CI job preamble
+1546430404,default,metadata,provider,libvirt
+1546430405,default,state-human-long,To stop this machine%!(VAGRANT_COMMA) run\n'vagrant halt'.
+`
+
+			scanner := ParseMachineReadable(strings.NewReader(output))
+
+			require.True(t, scanner.Scan())
+			assert.Equal(t, &OutputLine{
+				Timestamp: time.Unix(1546430404, 0),
+				Target:    "default",
+				Kind:      "metadata",
+				Data:      []string{"provider", "libvirt"},
+			}, scanner.Line())
+
+			require.True(t, scanner.Scan())
+			assert.Equal(t, &OutputLine{
+				Timestamp: time.Unix(1546430405, 0),
+				Target:    "default",
+				Kind:      "state-human-long",
+				Data:      []string{"To stop this machine, run\n'vagrant halt'."},
+			}, scanner.Line())
+
+			assert.False(t, scanner.Scan())
+			assert.Nil(t, scanner.Line())
+			assert.NoError(t, scanner.Err())
+		},
+	)
+
+	t.Run(
+		"with reader returning an error, it stops and returns the error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			scanner := ParseMachineReadable(iotest.ErrReader(errors.New("fake error")))
+
+			assert.False(t, scanner.Scan())
+			require.Error(t, scanner.Err())
+			assert.Contains(t, scanner.Err().Error(), "fake error")
+		},
+	)
+}
+
+func TestBoxesFromMachineReadable(t *testing.T) {
+	t.Parallel()
+
+	output := `
+1546015529,,ui,info,my-debian (libvirt%!(VAGRANT_COMMA) 0)
+1546015529,,box-name,my-debian
+1546015529,,box-provider,libvirt
+1546015529,,box-version,1.2.3
+1546015529,,ui,info,my-vbox-debian (virtualbox%!(VAGRANT_COMMA) 0)
+1546015529,,box-name,my-vbox-debian
+1546015529,,box-provider,virtualbox
+1546015529,,box-version,1.2.4
+`
+
+	boxes, err := BoxesFromMachineReadable(strings.NewReader(output))
+	require.NoError(t, err)
+	require.Len(t, boxes, 2)
+
+	assert.Equal(t, &Box{Name: "my-debian", Provider: "libvirt", Version: "1.2.3"}, boxes[0])
+	assert.Equal(t, &Box{Name: "my-vbox-debian", Provider: "virtualbox", Version: "1.2.4"}, boxes[1])
+}
+
+func TestStatusesFromMachineReadable(t *testing.T) {
+	t.Parallel()
+
+	output := `
+1546430404,default,metadata,provider,libvirt
+1546430404,default,provider-name,libvirt
+1546430404,default,state,shutoff
+1546430404,default,state-human-short,shutoff
+1546430404,,ui,info,Current machine states:
+`
+
+	statuses, err := StatusesFromMachineReadable(strings.NewReader(output))
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+
+	assert.Equal(t, "default", statuses[0].Name)
+	assert.Equal(t, "libvirt", statuses[0].Provider)
+	assert.Equal(t, "shutoff", statuses[0].State)
+	assert.Equal(t, MachineStateStopped, statuses[0].NormalizedState)
+}
+
+func TestUpResultFromMachineReadable(t *testing.T) {
+	t.Run(
+		"with captured output of `vagrant up`, it returns result per machine",
+		func(t *testing.T) {
+			t.Parallel()
+
+			output := `
+1546430404,default,metadata,provider,libvirt
+1546430404,default,action,up,start
+1546430440,default,ui,info,Running provisioner: shell...
+1546430464,default,action,up,end
+`
+
+			result, err := UpResultFromMachineReadable(strings.NewReader(output))
+			require.NoError(t, err)
+			require.Len(t, result.Machines, 1)
+
+			assert.Equal(t, &MachineResult{
+				Name:         "default",
+				Outcome:      MachineOutcomeSucceeded,
+				Provider:     "libvirt",
+				State:        "running",
+				Duration:     time.Minute,
+				Provisioners: []string{"shell"},
+			}, result.Machines[0])
+		},
+	)
+
+	t.Run(
+		"with reader returning an error, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			result, err := UpResultFromMachineReadable(iotest.ErrReader(errors.New("fake error")))
+			assert.Nil(t, result)
+			assert.Error(t, err)
+		},
+	)
+}
@@ -0,0 +1,130 @@
+package vagrant_go
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MachineOutcome is the outcome of an operation for a single machine.
+type MachineOutcome string
+
+const (
+	MachineOutcomeSucceeded MachineOutcome = "succeeded"
+	MachineOutcomeFailed    MachineOutcome = "failed"
+	// MachineOutcomeSkipped is reported for targeted machines that the operation did not run for.
+	MachineOutcomeSkipped MachineOutcome = "skipped"
+)
+
+// MachineResult is the result of an operation for a single machine.
+type MachineResult struct {
+	Name     string
+	Outcome  MachineOutcome
+	Provider string
+	// State is the raw state the machine is left in, when the operation succeeded, e.g. `running` after `Up`.
+	// It's empty otherwise, since the state is not known without querying it.
+	State string
+	// Duration is the time between start and end of the action. Timestamps have a resolution of one second.
+	Duration time.Duration
+	// Provisioners are the names of provisioners that ran, in order.
+	Provisioners []string
+}
+
+const runningProvisionerPrefix = "Running provisioner: "
+
+// machineResultsFromOutputLines returns a result per machine targeted by `action`, in order of appearance.
+// Targeted machines are reported in `metadata` lines and the action in `action,<action>,start|end` lines.
+// A machine, for which the action started, but did not end, failed.
+func machineResultsFromOutputLines(
+	outputLines []*vagrantOutputLine,
+	action string,
+	succeededState string,
+) []*MachineResult {
+	// NOTE: Use 0 element slice in case there's nothing to return
+	//noinspection GoPreferNilSlice
+	results := []*MachineResult{}
+	resultsByName := map[string]*MachineResult{}
+	startedAt := map[string]time.Time{}
+	endedAt := map[string]time.Time{}
+
+	for _, line := range outputLines {
+		if len(line.target) == 0 || len(line.data) < 2 {
+			continue
+		}
+
+		result, ok := resultsByName[line.target]
+
+		switch {
+		case line.kind == "metadata" && line.data[0] == "provider":
+			if !ok {
+				result = &MachineResult{
+					Name:         line.target,
+					Provisioners: []string{},
+				}
+				resultsByName[line.target] = result
+				results = append(results, result)
+			}
+
+			result.Provider = line.data[1]
+		case line.kind == "action" && line.data[0] == action && ok:
+			switch line.data[1] {
+			case "start":
+				startedAt[line.target] = parseOutputLineTimestamp(line.timestamp)
+			case "end":
+				endedAt[line.target] = parseOutputLineTimestamp(line.timestamp)
+			}
+		case line.kind == "ui" && ok:
+			provisioner, found := provisionerFromMessage(unescapeMachineReadable(line.data[1]))
+			if found {
+				result.Provisioners = append(result.Provisioners, provisioner)
+			}
+		}
+	}
+
+	for _, result := range results {
+		start, started := startedAt[result.Name]
+		end, ended := endedAt[result.Name]
+
+		switch {
+		case ended:
+			result.Outcome = MachineOutcomeSucceeded
+			result.State = succeededState
+		case started:
+			result.Outcome = MachineOutcomeFailed
+		default:
+			result.Outcome = MachineOutcomeSkipped
+		}
+
+		if started && ended {
+			result.Duration = end.Sub(start)
+		}
+	}
+
+	return results
+}
+
+// NOTE: Provisioners are reported as `Running provisioner: shell...` or `Running provisioner: setup (shell)...`
+// for named ones.
+func provisionerFromMessage(message string) (string, bool) {
+	index := strings.Index(message, runningProvisionerPrefix)
+	if index < 0 {
+		return "", false
+	}
+
+	provisioner := message[index+len(runningProvisionerPrefix):]
+	provisioner = strings.TrimSpace(strings.SplitN(provisioner, "\n", 2)[0])
+	provisioner = strings.TrimSuffix(provisioner, "...")
+
+	return provisioner, len(provisioner) > 0
+}
+
+// parseOutputLineTimestamp parses a timestamp of a machine readable line, that is in seconds since Unix epoch.
+// Zero time is returned for invalid timestamps.
+func parseOutputLineTimestamp(timestamp string) time.Time {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(seconds, 0)
+}
@@ -0,0 +1,99 @@
+package vagrant_go
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMachineResultsFromOutputLines(t *testing.T) {
+	t.Run(
+		"with machines for which the action ended, started only and did not start, it returns succeeded, failed and skipped results",
+		func(t *testing.T) {
+			t.Parallel()
+
+			outputLines, err := scanOutputLines(strings.NewReader(`
+1546430404,web,metadata,provider,libvirt
+1546430404,db,metadata,provider,virtualbox
+1546430404,cache,metadata,provider,libvirt
+1546430404,,ui,info,Bringing machine 'web' up with 'libvirt' provider...
+1546430404,web,action,up,start
+1546430404,db,action,up,start
+1546430420,web,ui,info,Running provisioner: shell...
+1546430425,web,ui,info,Running provisioner: setup (ansible)...
+1546430430,web,action,up,end
+1546430431,db,action,provision,start
+1546430432,db,action,provision,end
+`), false)
+			require.NoError(t, err)
+
+			results := machineResultsFromOutputLines(outputLines, "up", "running")
+			require.Len(t, results, 3)
+
+			assert.Equal(t, &MachineResult{
+				Name:         "web",
+				Outcome:      MachineOutcomeSucceeded,
+				Provider:     "libvirt",
+				State:        "running",
+				Duration:     26 * time.Second,
+				Provisioners: []string{"shell", "setup (ansible)"},
+			}, results[0])
+
+			assert.Equal(t, &MachineResult{
+				Name:         "db",
+				Outcome:      MachineOutcomeFailed,
+				Provider:     "virtualbox",
+				State:        "",
+				Duration:     0,
+				Provisioners: []string{},
+			}, results[1])
+
+			assert.Equal(t, &MachineResult{
+				Name:         "cache",
+				Outcome:      MachineOutcomeSkipped,
+				Provider:     "libvirt",
+				State:        "",
+				Duration:     0,
+				Provisioners: []string{},
+			}, results[2])
+		},
+	)
+
+	t.Run(
+		"with no output lines, it returns empty slice",
+		func(t *testing.T) {
+			t.Parallel()
+
+			results := machineResultsFromOutputLines([]*vagrantOutputLine{}, "up", "running")
+			require.NotNil(t, results)
+			assert.Empty(t, results)
+		},
+	)
+}
+
+func TestProvisionerFromMessage(t *testing.T) {
+	tests := []struct {
+		message     string
+		provisioner string
+		found       bool
+	}{
+		{message: "Running provisioner: shell...", provisioner: "shell", found: true},
+		{message: "==> web: Running provisioner: setup (ansible)...", provisioner: "setup (ansible)", found: true},
+		{message: "Machine booted and ready!", provisioner: "", found: false},
+	}
+
+	for _, subTest := range tests {
+		provisioner, found := provisionerFromMessage(subTest.message)
+		assert.Equal(t, subTest.provisioner, provisioner)
+		assert.Equal(t, subTest.found, found)
+	}
+}
+
+func TestParseOutputLineTimestamp(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, time.Unix(1546430404, 0), parseOutputLineTimestamp("1546430404"))
+	assert.True(t, parseOutputLineTimestamp("invalid").IsZero())
+}
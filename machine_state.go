@@ -0,0 +1,68 @@
+package vagrant_go
+
+import (
+	"strings"
+)
+
+// MachineState is a machine state normalized across providers.
+type MachineState string
+
+const (
+	MachineStateNotCreated MachineState = "not_created"
+	MachineStateRunning    MachineState = "running"
+	MachineStateStopped    MachineState = "stopped"
+	MachineStateSuspended  MachineState = "suspended"
+	MachineStateAborted    MachineState = "aborted"
+	MachineStateUnknown    MachineState = "unknown"
+)
+
+// NOTE: Raw states, that are the same for all providers. Hyper-V reports them capitalized, so they're compared
+// lowercased.
+var commonMachineStates = map[string]MachineState{
+	"not_created": MachineStateNotCreated,
+	"not created": MachineStateNotCreated,
+	"running":     MachineStateRunning,
+	"poweroff":    MachineStateStopped,
+	"shutoff":     MachineStateStopped,
+	"shutdown":    MachineStateStopped,
+	"stopped":     MachineStateStopped,
+	"off":         MachineStateStopped,
+	"not_running": MachineStateStopped,
+	"saved":       MachineStateSuspended,
+	"suspended":   MachineStateSuspended,
+	"paused":      MachineStateSuspended,
+	"aborted":     MachineStateAborted,
+	"crashed":     MachineStateAborted,
+}
+
+// NOTE: Raw states, that mean something different or are specific to a provider.
+var providerMachineStates = map[string]map[string]MachineState{
+	"virtualbox": {
+		"gurumeditation": MachineStateAborted,
+		"inaccessible":   MachineStateUnknown,
+	},
+	"libvirt": {
+		"pmsuspended": MachineStateSuspended,
+	},
+	"docker": {
+		"host_state_unknown": MachineStateUnknown,
+	},
+}
+
+// NormalizeMachineState returns the normalized state of raw state `rawState` reported by `provider`.
+// `MachineStateUnknown` is returned for states that are not known.
+func NormalizeMachineState(provider string, rawState string) MachineState {
+	state := strings.ToLower(strings.TrimSpace(rawState))
+
+	if providerStates, ok := providerMachineStates[strings.ToLower(provider)]; ok {
+		if machineState, ok := providerStates[state]; ok {
+			return machineState
+		}
+	}
+
+	if machineState, ok := commonMachineStates[state]; ok {
+		return machineState
+	}
+
+	return MachineStateUnknown
+}
@@ -0,0 +1,43 @@
+package vagrant_go
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNormalizeMachineState(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		provider string
+		rawState string
+		expected MachineState
+	}{
+		{provider: "virtualbox", rawState: "not_created", expected: MachineStateNotCreated},
+		{provider: "virtualbox", rawState: "running", expected: MachineStateRunning},
+		{provider: "virtualbox", rawState: "poweroff", expected: MachineStateStopped},
+		{provider: "virtualbox", rawState: "saved", expected: MachineStateSuspended},
+		{provider: "virtualbox", rawState: "aborted", expected: MachineStateAborted},
+		{provider: "virtualbox", rawState: "gurumeditation", expected: MachineStateAborted},
+		{provider: "virtualbox", rawState: "inaccessible", expected: MachineStateUnknown},
+		{provider: "libvirt", rawState: "shutoff", expected: MachineStateStopped},
+		{provider: "libvirt", rawState: "paused", expected: MachineStateSuspended},
+		{provider: "libvirt", rawState: "pmsuspended", expected: MachineStateSuspended},
+		{provider: "libvirt", rawState: "crashed", expected: MachineStateAborted},
+		{provider: "docker", rawState: "stopped", expected: MachineStateStopped},
+		{provider: "docker", rawState: "host_state_unknown", expected: MachineStateUnknown},
+		{provider: "hyperv", rawState: "Off", expected: MachineStateStopped},
+		{provider: "hyperv", rawState: "Running", expected: MachineStateRunning},
+		{provider: "hyperv", rawState: "Saved", expected: MachineStateSuspended},
+		{provider: "vmware_desktop", rawState: "not_running", expected: MachineStateStopped},
+		{provider: "vmware_desktop", rawState: "suspended", expected: MachineStateSuspended},
+		{provider: "", rawState: "running", expected: MachineStateRunning},
+		{provider: "libvirt", rawState: "something_new", expected: MachineStateUnknown},
+		{provider: "libvirt", rawState: "", expected: MachineStateUnknown},
+	}
+
+	for _, subTest := range tests {
+		actual := NormalizeMachineState(subTest.provider, subTest.rawState)
+		assert.Equal(t, subTest.expected, actual, "%s reporting `%s`", subTest.provider, subTest.rawState)
+	}
+}
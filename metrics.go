@@ -0,0 +1,222 @@
+package vagrant_go
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CommandOutcome is the outcome of a single vagrant invocation, as reported to `MetricsCollector`.
+type CommandOutcome string
+
+const (
+	CommandOutcomeSuccess CommandOutcome = "success"
+	CommandOutcomeFailure CommandOutcome = "failure"
+)
+
+// CommandMetric is a measurement of a single vagrant invocation.
+type CommandMetric struct {
+	// Operation is the vagrant subcommand, e.g. `up` or `box list`.
+	Operation string
+	Outcome   CommandOutcome
+	// ErrorClass is the class of the error reported by Vagrant, e.g. `Vagrant::Errors::VMNotCreatedError`.
+	// It's empty when the command succeeded, or Vagrant did not report an error.
+	ErrorClass string
+	// Provider is the first provider reported in the output. It's empty for commands not reporting any.
+	Provider string
+	Duration time.Duration
+}
+
+// MetricsCollector gets a `CommandMetric` reported for every vagrant invocation made through `Client`.
+// It's called concurrently, when the `Client` is used concurrently.
+type MetricsCollector interface {
+	ObserveCommand(metric *CommandMetric)
+}
+
+// metricsMiddleware reports every invocation to `collector` after it's done.
+func metricsMiddleware(collector MetricsCollector) Middleware {
+	return func(next CommandHandler) CommandHandler {
+		return func(ctx context.Context, spec *CommandSpec) ([]byte, error) {
+			startedAt := time.Now()
+
+			output, err := next(ctx, spec)
+			outputLines, _ := parseOutputLines(ctx, output)
+
+			metric := &CommandMetric{
+				Operation: operationName(spec.Args),
+				Outcome:   CommandOutcomeSuccess,
+				Duration:  time.Since(startedAt),
+			}
+
+			metric.Provider = providerFromOutputLines(outputLines)
+
+			if err != nil {
+				metric.Outcome = CommandOutcomeFailure
+				metric.ErrorClass, _, _ = findErrorExit(outputLines)
+			}
+
+			collector.ObserveCommand(metric)
+
+			return output, err
+		}
+	}
+}
+
+func providerFromOutputLines(outputLines []*vagrantOutputLine) string {
+	for _, line := range outputLines {
+		if line.kind == "metadata" && len(line.data) > 1 && line.data[0] == "provider" {
+			return line.data[1]
+		}
+	}
+
+	return ""
+}
+
+// DefaultDurationBuckets are upper bounds of duration histogram buckets in seconds, suited for VM lifecycle operations.
+var DefaultDurationBuckets = []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1800}
+
+type commandMetricLabels struct {
+	operation  string
+	outcome    CommandOutcome
+	errorClass string
+	provider   string
+}
+
+type durationHistogram struct {
+	// bucketCounts are cumulative, like in the Prometheus exposition format.
+	bucketCounts []uint64
+	count        uint64
+	sum          float64
+}
+
+// InMemoryMetricsCollector keeps counts and duration histograms of commands per operation, outcome, error class and
+// provider in memory. Use `WritePrometheus` to export them.
+type InMemoryMetricsCollector struct {
+	mutex      sync.Mutex
+	buckets    []float64
+	histograms map[commandMetricLabels]*durationHistogram
+}
+
+// NewInMemoryMetricsCollector returns a collector with duration histograms using `buckets`, that are upper bounds in
+// seconds. `DefaultDurationBuckets` are used, if empty.
+func NewInMemoryMetricsCollector(buckets []float64) *InMemoryMetricsCollector {
+	if len(buckets) == 0 {
+		buckets = DefaultDurationBuckets
+	}
+
+	sortedBuckets := append([]float64{}, buckets...)
+	sort.Float64s(sortedBuckets)
+
+	return &InMemoryMetricsCollector{
+		buckets:    sortedBuckets,
+		histograms: map[commandMetricLabels]*durationHistogram{},
+	}
+}
+
+func (c *InMemoryMetricsCollector) ObserveCommand(metric *CommandMetric) {
+	labels := commandMetricLabels{
+		operation:  metric.Operation,
+		outcome:    metric.Outcome,
+		errorClass: metric.ErrorClass,
+		provider:   metric.Provider,
+	}
+
+	seconds := metric.Duration.Seconds()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	histogram, ok := c.histograms[labels]
+	if !ok {
+		histogram = &durationHistogram{
+			bucketCounts: make([]uint64, len(c.buckets)),
+		}
+		c.histograms[labels] = histogram
+	}
+
+	for i, bucket := range c.buckets {
+		if seconds <= bucket {
+			histogram.bucketCounts[i]++
+		}
+	}
+
+	histogram.count++
+	histogram.sum += seconds
+}
+
+// WritePrometheus writes all collected metrics in the Prometheus text exposition format.
+// ref: https://prometheus.io/docs/instrumenting/exposition_formats/
+func (c *InMemoryMetricsCollector) WritePrometheus(writer io.Writer) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	labelsList := make([]commandMetricLabels, 0, len(c.histograms))
+	for labels := range c.histograms {
+		labelsList = append(labelsList, labels)
+	}
+
+	sort.Slice(labelsList, func(i, j int) bool {
+		return formatMetricLabels(labelsList[i]) < formatMetricLabels(labelsList[j])
+	})
+
+	var builder strings.Builder
+
+	builder.WriteString("# HELP vagrant_commands_total Total number of executed vagrant commands.\n")
+	builder.WriteString("# TYPE vagrant_commands_total counter\n")
+
+	for _, labels := range labelsList {
+		fmt.Fprintf(&builder, "vagrant_commands_total{%s} %d\n", formatMetricLabels(labels), c.histograms[labels].count)
+	}
+
+	builder.WriteString("# HELP vagrant_command_duration_seconds Duration of executed vagrant commands.\n")
+	builder.WriteString("# TYPE vagrant_command_duration_seconds histogram\n")
+
+	for _, labels := range labelsList {
+		histogram := c.histograms[labels]
+		formattedLabels := formatMetricLabels(labels)
+
+		for i, bucket := range c.buckets {
+			fmt.Fprintf(
+				&builder,
+				"vagrant_command_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				formattedLabels,
+				strconv.FormatFloat(bucket, 'g', -1, 64),
+				histogram.bucketCounts[i],
+			)
+		}
+
+		fmt.Fprintf(
+			&builder,
+			"vagrant_command_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n",
+			formattedLabels,
+			histogram.count,
+		)
+		fmt.Fprintf(
+			&builder,
+			"vagrant_command_duration_seconds_sum{%s} %s\n",
+			formattedLabels,
+			strconv.FormatFloat(histogram.sum, 'g', -1, 64),
+		)
+		fmt.Fprintf(&builder, "vagrant_command_duration_seconds_count{%s} %d\n", formattedLabels, histogram.count)
+	}
+
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
+
+var prometheusLabelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatMetricLabels(labels commandMetricLabels) string {
+	return fmt.Sprintf(
+		`operation="%s",outcome="%s",error_class="%s",provider="%s"`,
+		prometheusLabelValueEscaper.Replace(labels.operation),
+		prometheusLabelValueEscaper.Replace(string(labels.outcome)),
+		prometheusLabelValueEscaper.Replace(labels.errorClass),
+		prometheusLabelValueEscaper.Replace(labels.provider),
+	)
+}
@@ -0,0 +1,167 @@
+package vagrant_go
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingMetricsCollector struct {
+	mutex   sync.Mutex
+	metrics []*CommandMetric
+}
+
+func (c *recordingMetricsCollector) ObserveCommand(metric *CommandMetric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.metrics = append(c.metrics, metric)
+}
+
+func TestClient_Metrics(t *testing.T) {
+	t.Run(
+		"with succeeding command, it reports operation, outcome and provider",
+		func(t *testing.T) {
+			t.Parallel()
+
+			collector := &recordingMetricsCollector{}
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+			client.middleware = []Middleware{metricsMiddleware(collector)}
+			client.commandRunFunc = func(cmd string, args ...string) ([]byte, error) {
+				return []byte(`
+1546430404,default,metadata,provider,libvirt
+1546430404,default,action,up,start
+1546430464,default,action,up,end
+`), nil
+			}
+
+			_, err := client.Global.Up(DefaultUpOptions())
+			require.NoError(t, err)
+
+			require.Len(t, collector.metrics, 1)
+			assert.Equal(t, "up", collector.metrics[0].Operation)
+			assert.Equal(t, CommandOutcomeSuccess, collector.metrics[0].Outcome)
+			assert.Equal(t, "", collector.metrics[0].ErrorClass)
+			assert.Equal(t, "libvirt", collector.metrics[0].Provider)
+		},
+	)
+
+	t.Run(
+		"with failing command, it reports failure with error class",
+		func(t *testing.T) {
+			t.Parallel()
+
+			collector := &recordingMetricsCollector{}
+
+			client, err := NewClient(
+				&Config{Metrics: collector},
+				func(cmd string, args ...string) ([]byte, error) {
+					return []byte(`1546430404,,error-exit,Vagrant::Errors::BoxListFailed,Failed`), errors.New("fake error")
+				},
+				emptyLookPathFunc,
+			)
+			require.NoError(t, err)
+
+			_, err = client.Box.List()
+			require.Error(t, err)
+
+			require.Len(t, collector.metrics, 1)
+			assert.Equal(t, "box list", collector.metrics[0].Operation)
+			assert.Equal(t, CommandOutcomeFailure, collector.metrics[0].Outcome)
+			assert.Equal(t, "Vagrant::Errors::BoxListFailed", collector.metrics[0].ErrorClass)
+		},
+	)
+
+	t.Run(
+		"with unreadable output of succeeding command, metrics and tracing return no error of their own",
+		func(t *testing.T) {
+			t.Parallel()
+
+			collector := &recordingMetricsCollector{}
+
+			client := emptyTestClient(t)
+			client.middleware = []Middleware{metricsMiddleware(collector), tracingMiddleware(NewInMemorySpanRecorder())}
+			client.commandRunFunc = func(cmd string, args ...string) ([]byte, error) {
+				return bytes.Repeat([]byte("a,"), maxOutputLineSize), nil
+			}
+
+			_, err := client.runCommand(context.Background(), "--machine-readable", "status")
+			require.NoError(t, err)
+
+			require.Len(t, collector.metrics, 1)
+			assert.Equal(t, CommandOutcomeSuccess, collector.metrics[0].Outcome)
+		},
+	)
+}
+
+func TestInMemoryMetricsCollector_WritePrometheus(t *testing.T) {
+	t.Run(
+		"with observed commands, it writes counters and histograms per labels",
+		func(t *testing.T) {
+			t.Parallel()
+
+			collector := NewInMemoryMetricsCollector([]float64{60, 1})
+
+			collector.ObserveCommand(&CommandMetric{
+				Operation: "up",
+				Outcome:   CommandOutcomeSuccess,
+				Provider:  "libvirt",
+				Duration:  30 * time.Second,
+			})
+			collector.ObserveCommand(&CommandMetric{
+				Operation: "up",
+				Outcome:   CommandOutcomeSuccess,
+				Provider:  "libvirt",
+				Duration:  90 * time.Second,
+			})
+			collector.ObserveCommand(&CommandMetric{
+				Operation:  "box list",
+				Outcome:    CommandOutcomeFailure,
+				ErrorClass: `Vagrant::Errors::"Quoted"`,
+				Duration:   500 * time.Millisecond,
+			})
+
+			output := &bytes.Buffer{}
+			require.NoError(t, collector.WritePrometheus(output))
+
+			expected := `# HELP vagrant_commands_total Total number of executed vagrant commands.
+# TYPE vagrant_commands_total counter
+vagrant_commands_total{operation="box list",outcome="failure",error_class="Vagrant::Errors::\"Quoted\"",provider=""} 1
+vagrant_commands_total{operation="up",outcome="success",error_class="",provider="libvirt"} 2
+# HELP vagrant_command_duration_seconds Duration of executed vagrant commands.
+# TYPE vagrant_command_duration_seconds histogram
+vagrant_command_duration_seconds_bucket{operation="box list",outcome="failure",error_class="Vagrant::Errors::\"Quoted\"",provider="",le="1"} 1
+vagrant_command_duration_seconds_bucket{operation="box list",outcome="failure",error_class="Vagrant::Errors::\"Quoted\"",provider="",le="60"} 1
+vagrant_command_duration_seconds_bucket{operation="box list",outcome="failure",error_class="Vagrant::Errors::\"Quoted\"",provider="",le="+Inf"} 1
+vagrant_command_duration_seconds_sum{operation="box list",outcome="failure",error_class="Vagrant::Errors::\"Quoted\"",provider=""} 0.5
+vagrant_command_duration_seconds_count{operation="box list",outcome="failure",error_class="Vagrant::Errors::\"Quoted\"",provider=""} 1
+vagrant_command_duration_seconds_bucket{operation="up",outcome="success",error_class="",provider="libvirt",le="1"} 0
+vagrant_command_duration_seconds_bucket{operation="up",outcome="success",error_class="",provider="libvirt",le="60"} 1
+vagrant_command_duration_seconds_bucket{operation="up",outcome="success",error_class="",provider="libvirt",le="+Inf"} 2
+vagrant_command_duration_seconds_sum{operation="up",outcome="success",error_class="",provider="libvirt"} 120
+vagrant_command_duration_seconds_count{operation="up",outcome="success",error_class="",provider="libvirt"} 2
+`
+
+			assert.Equal(t, expected, output.String())
+		},
+	)
+
+	t.Run(
+		"with no observed commands, it writes only metric descriptions",
+		func(t *testing.T) {
+			t.Parallel()
+
+			output := &bytes.Buffer{}
+			require.NoError(t, NewInMemoryMetricsCollector(nil).WritePrometheus(output))
+
+			assert.NotContains(t, output.String(), "vagrant_commands_total{")
+		},
+	)
+}
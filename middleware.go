@@ -0,0 +1,21 @@
+package vagrant_go
+
+import (
+	"context"
+)
+
+// CommandHandler executes the vagrant command described by `spec` and returns its combined output.
+type CommandHandler func(ctx context.Context, spec *CommandSpec) ([]byte, error)
+
+// Middleware wraps execution of vagrant commands. It sees the spec before calling `next` and its output and error
+// after. It may also change the spec, e.g. add to `Env`, or not call `next` at all.
+type Middleware func(next CommandHandler) CommandHandler
+
+// chainMiddleware returns a handler calling `middleware` in order, with `handler` last.
+func chainMiddleware(middleware []Middleware, handler CommandHandler) CommandHandler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+
+	return handler
+}
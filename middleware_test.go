@@ -0,0 +1,177 @@
+package vagrant_go
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+func TestChainMiddleware(t *testing.T) {
+	t.Parallel()
+
+	calls := []string{}
+
+	recordingMiddleware := func(name string) Middleware {
+		return func(next CommandHandler) CommandHandler {
+			return func(ctx context.Context, spec *CommandSpec) ([]byte, error) {
+				calls = append(calls, name+" before")
+				output, err := next(ctx, spec)
+				calls = append(calls, name+" after")
+
+				return output, err
+			}
+		}
+	}
+
+	handler := chainMiddleware(
+		[]Middleware{recordingMiddleware("first"), recordingMiddleware("second")},
+		func(ctx context.Context, spec *CommandSpec) ([]byte, error) {
+			calls = append(calls, "handler")
+			return []byte("output"), nil
+		},
+	)
+
+	output, err := handler(context.Background(), &CommandSpec{})
+	require.NoError(t, err)
+	assert.Equal(t, "output", string(output))
+
+	assert.Equal(t, []string{"first before", "second before", "handler", "second after", "first after"}, calls)
+}
+
+func TestClient_Middleware(t *testing.T) {
+	t.Run(
+		"with middleware, it's given the command spec before execution and the output and error after",
+		func(t *testing.T) {
+			t.Parallel()
+
+			var seenSpec *CommandSpec
+			var seenOutput []byte
+			var seenErr error
+
+			middleware := func(next CommandHandler) CommandHandler {
+				return func(ctx context.Context, spec *CommandSpec) ([]byte, error) {
+					seenSpec = spec
+
+					seenOutput, seenErr = next(ctx, spec)
+					return seenOutput, seenErr
+				}
+			}
+
+			client, err := NewClient(
+				&Config{Middleware: []Middleware{middleware}},
+				func(cmd string, args ...string) ([]byte, error) {
+					return []byte("1546430404,default,state,running\n"), errors.New("fake error")
+				},
+				emptyLookPathFunc,
+			)
+			require.NoError(t, err)
+
+			_, err = client.Global.Status(DefaultStatusOptions())
+			assert.Error(t, err)
+
+			workingDirectory, err := os.Getwd()
+			require.NoError(t, err)
+
+			require.NotNil(t, seenSpec)
+			assert.Equal(t, "vagrant", seenSpec.Binary)
+			assert.Equal(t, []string{"--machine-readable", "status"}, seenSpec.Args)
+			assert.Equal(t, workingDirectory, seenSpec.WorkingDirectory)
+			assert.Equal(t, os.Environ(), seenSpec.Env)
+			assert.Equal(t, "1546430404,default,state,running\n", string(seenOutput))
+			assert.EqualError(t, seenErr, "fake error")
+		},
+	)
+
+	t.Run(
+		"with middleware not calling the next handler, it doesn't execute the command",
+		func(t *testing.T) {
+			t.Parallel()
+
+			middleware := func(next CommandHandler) CommandHandler {
+				return func(ctx context.Context, spec *CommandSpec) ([]byte, error) {
+					return []byte("1546430404,default,state,running\n"), nil
+				}
+			}
+
+			client, err := NewClient(
+				&Config{Middleware: []Middleware{middleware}},
+				func(cmd string, args ...string) ([]byte, error) {
+					t.Fatalf("unexpected execution of `%s %v`", cmd, args)
+					return nil, nil
+				},
+				emptyLookPathFunc,
+			)
+			require.NoError(t, err)
+
+			statuses, err := client.Global.Status(DefaultStatusOptions())
+			require.NoError(t, err)
+			require.Len(t, statuses, 1)
+			assert.Equal(t, "running", statuses[0].State)
+		},
+	)
+
+	t.Run(
+		"with middleware changing the spec, it executes the changed command",
+		func(t *testing.T) {
+			t.Parallel()
+
+			var executedCmd string
+
+			middleware := func(next CommandHandler) CommandHandler {
+				return func(ctx context.Context, spec *CommandSpec) ([]byte, error) {
+					spec.Binary = "/opt/vagrant/bin/vagrant"
+					return next(ctx, spec)
+				}
+			}
+
+			client, err := NewClient(
+				&Config{Middleware: []Middleware{middleware}},
+				func(cmd string, args ...string) ([]byte, error) {
+					executedCmd = cmd
+					return nil, nil
+				},
+				emptyLookPathFunc,
+			)
+			require.NoError(t, err)
+
+			err = client.Global.Halt(DefaultHaltOptions())
+			require.NoError(t, err)
+			assert.Equal(t, "/opt/vagrant/bin/vagrant", executedCmd)
+		},
+	)
+
+	t.Run(
+		"with middleware changing working directory and environment, it executes the command with them",
+		func(t *testing.T) {
+			t.Parallel()
+
+			var executedSpec *CommandSpec
+
+			middleware := func(next CommandHandler) CommandHandler {
+				return func(ctx context.Context, spec *CommandSpec) ([]byte, error) {
+					spec.WorkingDirectory = "/tmp/example"
+					spec.Env = append(spec.Env, "VAGRANT_DEFAULT_PROVIDER=libvirt")
+					return next(ctx, spec)
+				}
+			}
+
+			client, err := NewClient(&Config{Middleware: []Middleware{middleware}}, nil, emptyLookPathFunc)
+			require.NoError(t, err)
+
+			client.commandRunContextFunc = func(ctx context.Context, spec *CommandSpec) ([]byte, error) {
+				executedSpec = spec
+				return nil, nil
+			}
+
+			err = client.Global.Halt(DefaultHaltOptions())
+			require.NoError(t, err)
+
+			require.NotNil(t, executedSpec)
+			assert.Equal(t, "/tmp/example", executedSpec.WorkingDirectory)
+			assert.Contains(t, executedSpec.Env, "VAGRANT_DEFAULT_PROVIDER=libvirt")
+		},
+	)
+}
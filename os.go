@@ -1,7 +1,9 @@
 package vagrant_go
 
 import (
+	"github.com/palantir/stacktrace"
 	"os"
+	"path/filepath"
 )
 
 // Compile-time proof of interface implementation.
@@ -10,6 +12,9 @@ var _ OsExecutor = (*osExecutor)(nil)
 type OsExecutor interface {
 	Chdir(dir string) error
 	Getwd() (string, error)
+	Stat(name string) (os.FileInfo, error)
+	Environ() []string
+	Getenv(key string) string
 }
 
 type osExecutor struct{}
@@ -21,3 +26,56 @@ func (ex *osExecutor) Chdir(dir string) error {
 func (ex *osExecutor) Getwd() (string, error) {
 	return os.Getwd()
 }
+
+func (ex *osExecutor) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (ex *osExecutor) Environ() []string {
+	return os.Environ()
+}
+
+func (ex *osExecutor) Getenv(key string) string {
+	return os.Getenv(key)
+}
+
+// inWorkingDirectory calls `fn` from within `workingDirectory`, if not empty, and changes back to the current working
+// directory afterwards.
+func inWorkingDirectory(osExecutor OsExecutor, workingDirectory string, fn func() error) error {
+	if len(workingDirectory) == 0 {
+		return fn()
+	}
+
+	oldWorkingDir, err := osExecutor.Getwd()
+	if err != nil {
+		return err
+	}
+
+	err = osExecutor.Chdir(workingDirectory)
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+
+	chdirErr := osExecutor.Chdir(oldWorkingDir)
+	if err != nil {
+		return err
+	}
+
+	return chdirErr
+}
+
+// resolveWorkingDirectory returns the absolute path of `workingDirectory`, or the current working directory if empty.
+func resolveWorkingDirectory(osExecutor OsExecutor, workingDirectory string) (string, error) {
+	if filepath.IsAbs(workingDirectory) {
+		return workingDirectory, nil
+	}
+
+	currentWorkingDirectory, err := osExecutor.Getwd()
+	if err != nil {
+		return "", stacktrace.Propagate(err, "failed to get current working directory")
+	}
+
+	return filepath.Join(currentWorkingDirectory, workingDirectory), nil
+}
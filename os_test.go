@@ -15,6 +15,11 @@ func TestChdir(t *testing.T) {
 	defer os.RemoveAll(tmpDir)
 	require.NoError(t, err)
 
+	// NOTE: Change back before the directory is removed, so that other tests keep a working directory
+	oldDir, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldDir)
+
 	osExecutor := &osExecutor{}
 	err = osExecutor.Chdir(tmpDir)
 	require.NoError(t, err)
@@ -36,3 +41,19 @@ func TestGetwd(t *testing.T) {
 
 	assert.Equal(t, actualDir, dir)
 }
+
+func TestStat(t *testing.T) {
+	t.Parallel()
+
+	tmpFile, err := ioutil.TempFile("", "example")
+	require.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	osExecutor := &osExecutor{}
+	fileInfo, err := osExecutor.Stat(tmpFile.Name())
+	require.NoError(t, err)
+	assert.False(t, fileInfo.IsDir())
+
+	_, err = osExecutor.Stat(tmpFile.Name() + "-missing")
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,78 @@
+package vagrant_go
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// maxOutputLineSize is the maximum size of a single line of machine readable output.
+const maxOutputLineSize = 16 * 1024 * 1024
+
+// vagrantOutputScanner parses machine readable output incrementally, one line at a time. Memory use is bounded by
+// the longest line, instead of the whole output, as long as `reader` streams it. Output of commands executed by
+// `Client` is buffered whole before it's scanned. Lines that are not machine readable are skipped.
+type vagrantOutputScanner struct {
+	scanner *bufio.Scanner
+	// skipIgnored skips the `metadata`, `ui` and `action` lines, like `vagrantOutputLineFromString`.
+	skipIgnored bool
+	line        *vagrantOutputLine
+}
+
+func newVagrantOutputScanner(reader io.Reader, skipIgnored bool) *vagrantOutputScanner {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, maxOutputLineSize)
+
+	return &vagrantOutputScanner{
+		scanner:     scanner,
+		skipIgnored: skipIgnored,
+	}
+}
+
+// Scan advances to the next machine readable line. It returns false when there are no more lines or reading failed.
+func (s *vagrantOutputScanner) Scan() bool {
+	for s.scanner.Scan() {
+		// NOTE: Lines are checked before they're copied out of the scanner buffer, so that skipped lines are
+		// never allocated.
+		lineBytes := bytes.TrimSpace(s.scanner.Bytes())
+		if bytes.Count(lineBytes, []byte{','}) < 3 {
+			continue
+		}
+
+		// NOTE: All fields of the line are slices of this single copy.
+		line := parseVagrantOutputLine(string(lineBytes))
+		if line == nil || (s.skipIgnored && isIgnoredOutputLine(line)) {
+			continue
+		}
+
+		s.line = line
+		return true
+	}
+
+	s.line = nil
+	return false
+}
+
+// Line returns the line read by the last call of `Scan`.
+func (s *vagrantOutputScanner) Line() *vagrantOutputLine {
+	return s.line
+}
+
+// Err returns the first error that occurred while reading.
+func (s *vagrantOutputScanner) Err() error {
+	return s.scanner.Err()
+}
+
+// scanOutputLines reads all machine readable lines from `reader`.
+func scanOutputLines(reader io.Reader, skipIgnored bool) ([]*vagrantOutputLine, error) {
+	scanner := newVagrantOutputScanner(reader, skipIgnored)
+
+	//noinspection GoPreferNilSlice
+	outputLines := []*vagrantOutputLine{}
+
+	for scanner.Scan() {
+		outputLines = append(outputLines, scanner.Line())
+	}
+
+	return outputLines, scanner.Err()
+}
@@ -0,0 +1,177 @@
+package vagrant_go
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestScanOutputLines(t *testing.T) {
+	output := `
+Bringing machine 'default' up with 'libvirt' provider...
+1546430404,default,metadata,provider,libvirt
+1546430404,default,ui,info,Running provisioner: shell...
+1546430405,default,state,running
+
+1546430405,default,state-human-long,To stop this machine%!(VAGRANT_COMMA) run\n'vagrant halt'.
+`
+
+	t.Run(
+		"with ignored lines not skipped, it returns all machine readable lines",
+		func(t *testing.T) {
+			t.Parallel()
+
+			lines, err := scanOutputLines(strings.NewReader(output), false)
+			require.NoError(t, err)
+			require.Len(t, lines, 4)
+
+			assert.Equal(t, &vagrantOutputLine{
+				timestamp: "1546430404",
+				target:    "default",
+				kind:      "metadata",
+				data:      []string{"provider", "libvirt"},
+			}, lines[0])
+			assert.Equal(t, "ui", lines[1].kind)
+			assert.Equal(t, "state", lines[2].kind)
+			assert.Equal(t, "state-human-long", lines[3].kind)
+		},
+	)
+
+	t.Run(
+		"with ignored lines skipped, it returns the same lines as `vagrantOutputLineFromString`",
+		func(t *testing.T) {
+			t.Parallel()
+
+			lines, err := scanOutputLines(strings.NewReader(output), true)
+			require.NoError(t, err)
+
+			expected := []*vagrantOutputLine{}
+			for _, outputLine := range strings.Split(output, "\n") {
+				line := vagrantOutputLineFromString(outputLine)
+				if line != nil {
+					expected = append(expected, line)
+				}
+			}
+
+			assert.Equal(t, expected, lines)
+			assert.Len(t, lines, 2)
+		},
+	)
+
+	t.Run(
+		"with line longer than the maximum size, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			longLine := "1546430404,default,ui,info," + strings.Repeat("a", maxOutputLineSize)
+
+			_, err := scanOutputLines(strings.NewReader(longLine), false)
+			assert.Error(t, err)
+		},
+	)
+
+	t.Run(
+		"with reader returning an error, it returns the error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			_, err := scanOutputLines(iotest.ErrReader(errors.New("fake error")), false)
+			assert.EqualError(t, err, "fake error")
+		},
+	)
+}
+
+// largeMachineReadableOutput returns output like that of a long provisioning run with `--debug`.
+func largeMachineReadableOutput(lines int) string {
+	var builder strings.Builder
+
+	for i := 0; i < lines; i++ {
+		switch i % 4 {
+		case 0:
+			fmt.Fprintf(&builder, " INFO interface: info: Machine: ui %d\n", i)
+		case 1:
+			fmt.Fprintf(&builder, "1546430404,default,ui,info,==> default: Running task %d%%!(VAGRANT_COMMA) please wait\\n\n", i)
+		case 2:
+			fmt.Fprintf(&builder, "1546430404,default,state,running\n")
+		default:
+			fmt.Fprintf(&builder, "1546430404,,box-name,box-%d\n", i)
+		}
+	}
+
+	return builder.String()
+}
+
+// legacyParseMachineReadableOutput is the implementation replaced by `scanOutputLines`. It's kept for comparison.
+func legacyParseMachineReadableOutput(output string) []*vagrantOutputLine {
+	vagrantOutputLines := []*vagrantOutputLine{}
+
+	for _, outputLine := range strings.Split(output, "\n") {
+		trimmedStr := strings.TrimSpace(outputLine)
+		splitLines := strings.SplitN(trimmedStr, ",", 4)
+
+		vagrantLines := []string{}
+		for _, line := range splitLines {
+			if contains(ignoredOutputLines, line) {
+				continue
+			}
+
+			vagrantLines = append(vagrantLines, line)
+		}
+
+		if len(vagrantLines) < 4 {
+			continue
+		}
+
+		dataLines := []string{}
+		for _, line := range strings.Split(vagrantLines[3], ",") {
+			dataLines = append(dataLines, line)
+		}
+
+		vagrantOutputLines = append(vagrantOutputLines, &vagrantOutputLine{
+			timestamp: vagrantLines[0],
+			target:    vagrantLines[1],
+			kind:      vagrantLines[2],
+			data:      dataLines,
+		})
+	}
+
+	return vagrantOutputLines
+}
+
+func BenchmarkParseMachineReadableOutput(b *testing.B) {
+	output := largeMachineReadableOutput(100000)
+
+	b.Run("legacy", func(b *testing.B) {
+		b.SetBytes(int64(len(output)))
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			legacyParseMachineReadableOutput(output)
+		}
+	})
+
+	b.Run("scanner", func(b *testing.B) {
+		b.SetBytes(int64(len(output)))
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			_, _ = scanOutputLines(strings.NewReader(output), true)
+		}
+	})
+
+	b.Run("scanner streaming", func(b *testing.B) {
+		b.SetBytes(int64(len(output)))
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			scanner := newVagrantOutputScanner(strings.NewReader(output), true)
+			for scanner.Scan() {
+				_ = scanner.Line()
+			}
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package vagrant_go
+
+// CommandSpec is a vagrant invocation, as it would be executed by an operation. Changes of middleware to any of its
+// fields are used by the executed command, except for `WorkingDirectory` and `Env` when a custom `commandRunFunc` is
+// given to `NewClient`, since it's given only the binary and args.
+type CommandSpec struct {
+	Binary string
+	// Args are the arguments of `Binary`, including `--machine-readable`.
+	Args []string
+	// WorkingDirectory is the absolute path of the directory the command would be executed from. The current working
+	// directory is used, when empty.
+	WorkingDirectory string
+	// Env is the environment the command would inherit, in `key=value` form. The environment of the current process
+	// is used, when nil.
+	Env []string
+}
+
+// NOTE: Plans never detect the installed version, since that executes `vagrant version`. Flags and commands gated by
+// version are planned as supported, unless the version is detected already.
+
+func (api *globalAPI) PlanUp(options *UpOptions) (*CommandSpec, error) {
+	supportsInstallProvider := capabilityInstallProvider.isSupportedBy(api.client.cachedVersion())
+	return api.plan(options.WorkingDirectory, upArgs(options, supportsInstallProvider)...)
+}
+
+func (api *globalAPI) PlanDestroy(options *DestroyOptions) (*CommandSpec, error) {
+	return api.plan(options.WorkingDirectory, destroyArgs(options)...)
+}
+
+func (api *globalAPI) PlanStatus(options *StatusOptions) (*CommandSpec, error) {
+	return api.plan(options.WorkingDirectory, statusArgs(options)...)
+}
+
+func (api *globalAPI) PlanHalt(options *HaltOptions) (*CommandSpec, error) {
+	return api.plan(options.WorkingDirectory, haltArgs(options)...)
+}
+
+func (api *globalAPI) PlanResume(options *ResumeOptions) (*CommandSpec, error) {
+	return api.plan(options.WorkingDirectory, resumeArgs(options)...)
+}
+
+func (api *globalAPI) PlanReload(options *ReloadOptions) (*CommandSpec, error) {
+	return api.plan(options.WorkingDirectory, reloadArgs(options)...)
+}
+
+func (api *globalAPI) PlanProvision(options *ProvisionOptions) (*CommandSpec, error) {
+	return api.plan(options.WorkingDirectory, provisionArgs(options)...)
+}
+
+func (api *globalAPI) PlanSshConfig(options *SshConfigOptions) (*CommandSpec, error) {
+	return api.plan(options.WorkingDirectory, sshConfigArgs(options)...)
+}
+
+func (api *globalAPI) PlanValidate(options *ValidateOptions) (*CommandSpec, error) {
+	return api.plan(options.WorkingDirectory, validateArgs(options)...)
+}
+
+func (api *globalAPI) PlanInit(options *InitOptions) (*CommandSpec, error) {
+	args, err := initArgs(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return api.plan(options.WorkingDirectory, args...)
+}
+
+func (api *globalAPI) PlanUpload(
+	machine string,
+	source string,
+	destination string,
+	options *UploadOptions,
+) (*CommandSpec, error) {
+	err := capabilityUpload.require(api.client.cachedVersion())
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := uploadArgs(machine, source, destination, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return api.plan(options.WorkingDirectory, args...)
+}
+
+func (api *globalAPI) PlanWinrmConfig(options *WinrmConfigOptions) (*CommandSpec, error) {
+	err := capabilityWinrmConfig.require(api.client.cachedVersion())
+	if err != nil {
+		return nil, err
+	}
+
+	return api.plan(options.WorkingDirectory, winrmConfigArgs(options)...)
+}
+
+func (api *boxAPI) PlanList() (*CommandSpec, error) {
+	return api.client.plan(api.client.osExecutor, "", boxListArgs()...)
+}
+
+func (api *boxAPI) PlanAdd(name string, options *BoxAddOptions) (*CommandSpec, error) {
+	args, err := boxAddArgs(name, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return api.client.plan(api.client.osExecutor, "", args...)
+}
+
+func (api *globalAPI) plan(workingDirectory string, args ...string) (*CommandSpec, error) {
+	return api.client.plan(api.osExecutor, workingDirectory, args...)
+}
+
+// plan returns the spec of a machine readable command executed from within `workingDirectory`, if not empty,
+// or the current working directory otherwise.
+func (c *Client) plan(osExecutor OsExecutor, workingDirectory string, args ...string) (*CommandSpec, error) {
+	workingDirectory, err := resolveWorkingDirectory(osExecutor, workingDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CommandSpec{
+		Binary:           c.Config.BinaryName,
+		Args:             append([]string{"--machine-readable"}, args...),
+		WorkingDirectory: workingDirectory,
+		Env:              osExecutor.Environ(),
+	}, nil
+}
@@ -0,0 +1,395 @@
+package vagrant_go
+
+import (
+	"errors"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func planTestClient(t *testing.T) (*Client, *fakeOsExecutor) {
+	fakeOsExecutor := &fakeOsExecutor{}
+	fakeOsExecutor.On("Getwd").Return("/tmp/anotherexample", nil)
+	fakeOsExecutor.On("Environ").Return([]string{"VAGRANT_HOME=/tmp/vagrant.d"})
+
+	client := emptyTestClient(t)
+	client.commandRunFunc = func(cmd string, args ...string) ([]byte, error) {
+		t.Fatalf("unexpected execution of `%s %v`", cmd, args)
+		return nil, nil
+	}
+
+	return client, fakeOsExecutor
+}
+
+func TestGlobalAPI_PlanUp(t *testing.T) {
+	t.Run(
+		"with options, it returns the command `Up` would execute without executing it",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client, fakeOsExecutor := planTestClient(t)
+			api := &globalAPI{client: client, osExecutor: fakeOsExecutor}
+
+			options := DefaultUpOptions()
+			options.WorkingDirectory = "/tmp/example"
+			options.Provider = "libvirt"
+			options.Targets = []string{"web"}
+
+			spec, err := api.PlanUp(options)
+			require.NoError(t, err)
+
+			assert.Equal(t, &CommandSpec{
+				Binary: "vagrant",
+				Args: []string{
+					"--machine-readable",
+					"up",
+					"--provision",
+					"--destroy-on-error",
+					"--parallel",
+					"--provider", "libvirt",
+					"--install-provider",
+					"web",
+				},
+				WorkingDirectory: "/tmp/example",
+				Env:              []string{"VAGRANT_HOME=/tmp/vagrant.d"},
+			}, spec)
+		},
+	)
+
+	t.Run(
+		"with relative working directory, it returns it resolved against the current working directory",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client, fakeOsExecutor := planTestClient(t)
+			api := &globalAPI{client: client, osExecutor: fakeOsExecutor}
+
+			options := DefaultUpOptions()
+			options.WorkingDirectory = "example"
+
+			spec, err := api.PlanUp(options)
+			require.NoError(t, err)
+			assert.Equal(t, "/tmp/anotherexample/example", spec.WorkingDirectory)
+		},
+	)
+
+	t.Run(
+		"with no working directory and failing to get the current one, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			fakeOsExecutor := &fakeOsExecutor{}
+			fakeOsExecutor.On("Getwd").Return("", errors.New("fake error"))
+
+			api := &globalAPI{client: emptyTestClient(t), osExecutor: fakeOsExecutor}
+
+			spec, err := api.PlanUp(DefaultUpOptions())
+			assert.Nil(t, spec)
+			assert.Error(t, err)
+		},
+	)
+}
+
+func TestGlobalAPI_PlanDestroy(t *testing.T) {
+	t.Parallel()
+
+	client, fakeOsExecutor := planTestClient(t)
+	api := &globalAPI{client: client, osExecutor: fakeOsExecutor}
+
+	options := DefaultDestroyOptions()
+	options.Targets = []string{"web", "db"}
+
+	spec, err := api.PlanDestroy(options)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"--machine-readable", "destroy", "--force", "--parallel", "web", "db"}, spec.Args)
+	assert.Equal(t, "/tmp/anotherexample", spec.WorkingDirectory)
+}
+
+func TestGlobalAPI_PlanHalt(t *testing.T) {
+	t.Parallel()
+
+	client, fakeOsExecutor := planTestClient(t)
+	api := &globalAPI{client: client, osExecutor: fakeOsExecutor}
+
+	options := DefaultHaltOptions()
+	options.Force = true
+
+	spec, err := api.PlanHalt(options)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"--machine-readable", "halt", "--force"}, spec.Args)
+}
+
+func TestGlobalAPI_PlanReload(t *testing.T) {
+	t.Parallel()
+
+	client, fakeOsExecutor := planTestClient(t)
+	api := &globalAPI{client: client, osExecutor: fakeOsExecutor}
+
+	options := DefaultReloadOptions()
+	options.Provision = true
+	options.Targets = []string{"web"}
+
+	spec, err := api.PlanReload(options)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"--machine-readable", "reload", "--provision", "web"}, spec.Args)
+}
+
+func TestGlobalAPI_PlanProvision(t *testing.T) {
+	t.Parallel()
+
+	client, fakeOsExecutor := planTestClient(t)
+	api := &globalAPI{client: client, osExecutor: fakeOsExecutor}
+
+	options := DefaultProvisionOptions()
+	options.ProvisionWith = []string{"shell", "ansible"}
+
+	spec, err := api.PlanProvision(options)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"--machine-readable", "provision", "--provision-with", "shell,ansible"}, spec.Args)
+}
+
+func TestGlobalAPI_PlanSshConfig(t *testing.T) {
+	t.Parallel()
+
+	client, fakeOsExecutor := planTestClient(t)
+	api := &globalAPI{client: client, osExecutor: fakeOsExecutor}
+
+	options := DefaultSshConfigOptions()
+	options.Name = "example"
+
+	spec, err := api.PlanSshConfig(options)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"--machine-readable", "ssh-config", "--name", "example"}, spec.Args)
+}
+
+func TestGlobalAPI_PlanValidate(t *testing.T) {
+	t.Parallel()
+
+	client, fakeOsExecutor := planTestClient(t)
+	api := &globalAPI{client: client, osExecutor: fakeOsExecutor}
+
+	options := DefaultValidateOptions()
+	options.IgnoreProvider = true
+	options.Name = "web"
+
+	spec, err := api.PlanValidate(options)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"--machine-readable", "validate", "--ignore-provider", "web"}, spec.Args)
+}
+
+func TestGlobalAPI_PlanInit(t *testing.T) {
+	t.Run(
+		"with options, it returns the command `Init` would execute without executing it",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client, fakeOsExecutor := planTestClient(t)
+			api := &globalAPI{client: client, osExecutor: fakeOsExecutor}
+
+			options := DefaultInitOptions()
+			options.BoxName = "ubuntu/bionic64"
+			options.Force = true
+			options.Minimal = true
+
+			spec, err := api.PlanInit(options)
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{"--machine-readable", "init", "--force", "--minimal", "ubuntu/bionic64"}, spec.Args)
+		},
+	)
+
+	t.Run(
+		"with 'BoxURL' and no 'BoxName', it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client, fakeOsExecutor := planTestClient(t)
+			api := &globalAPI{client: client, osExecutor: fakeOsExecutor}
+
+			options := DefaultInitOptions()
+			options.BoxURL = "https://example.com/example.box"
+
+			spec, err := api.PlanInit(options)
+			assert.Nil(t, spec)
+			assert.Error(t, err)
+		},
+	)
+}
+
+func TestGlobalAPI_PlanUpload(t *testing.T) {
+	t.Run(
+		"with options, it returns the command `Upload` would execute without executing it",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client, fakeOsExecutor := planTestClient(t)
+			api := &globalAPI{client: client, osExecutor: fakeOsExecutor}
+
+			options := DefaultUploadOptions()
+			options.CompressionType = "zip"
+
+			spec, err := api.PlanUpload("web", "/tmp/setup.sh", "", options)
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{
+				"--machine-readable",
+				"upload",
+				"--compress",
+				"--compression-type", "zip",
+				"/tmp/setup.sh",
+				"setup.sh",
+				"web",
+			}, spec.Args)
+		},
+	)
+
+	t.Run(
+		"with unsupported 'CompressionType', it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client, fakeOsExecutor := planTestClient(t)
+			api := &globalAPI{client: client, osExecutor: fakeOsExecutor}
+
+			options := DefaultUploadOptions()
+			options.CompressionType = "rar"
+
+			spec, err := api.PlanUpload("web", "/tmp/setup.sh", "", options)
+			assert.Nil(t, spec)
+			assert.Error(t, err)
+		},
+	)
+}
+
+func TestGlobalAPI_PlanWinrmConfig(t *testing.T) {
+	t.Parallel()
+
+	client, fakeOsExecutor := planTestClient(t)
+	api := &globalAPI{client: client, osExecutor: fakeOsExecutor}
+
+	options := DefaultWinrmConfigOptions()
+	options.Host = "windows"
+
+	spec, err := api.PlanWinrmConfig(options)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"--machine-readable", "winrm-config", "--host", "windows"}, spec.Args)
+}
+
+func TestBoxAPI_Plan(t *testing.T) {
+	t.Run(
+		"with `PlanList`, it returns the command `List` would execute without executing it",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client, fakeOsExecutor := planTestClient(t)
+			client.osExecutor = fakeOsExecutor
+
+			spec, err := client.Box.PlanList()
+			require.NoError(t, err)
+
+			assert.Equal(t, &CommandSpec{
+				Binary:           "vagrant",
+				Args:             []string{"--machine-readable", "box", "list"},
+				WorkingDirectory: "/tmp/anotherexample",
+				Env:              []string{"VAGRANT_HOME=/tmp/vagrant.d"},
+			}, spec)
+		},
+	)
+
+	t.Run(
+		"with `PlanAdd`, it returns the command `Add` would execute without executing it",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client, fakeOsExecutor := planTestClient(t)
+			client.osExecutor = fakeOsExecutor
+
+			options := DefaultBoxAddOptions()
+			options.Provider = "libvirt"
+
+			spec, err := client.Box.PlanAdd("ubuntu/bionic64", options)
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{
+				"--machine-readable",
+				"box",
+				"add",
+				"--provider", "libvirt",
+				"ubuntu/bionic64",
+			}, spec.Args)
+		},
+	)
+
+	t.Run(
+		"with `PlanAdd` and no name, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client, fakeOsExecutor := planTestClient(t)
+			client.osExecutor = fakeOsExecutor
+
+			spec, err := client.Box.PlanAdd("", DefaultBoxAddOptions())
+			assert.Nil(t, spec)
+			assert.Error(t, err)
+		},
+	)
+}
+
+func TestGlobalAPI_Plan(t *testing.T) {
+	t.Run(
+		"with new client, plans don't execute anything, also not version detection",
+		func(t *testing.T) {
+			t.Parallel()
+
+			var executedArgs [][]string
+
+			client, err := NewClient(
+				nil,
+				func(cmd string, args ...string) ([]byte, error) {
+					executedArgs = append(executedArgs, args)
+					return nil, nil
+				},
+				emptyLookPathFunc,
+			)
+			require.NoError(t, err)
+
+			upSpec, err := client.Global.PlanUp(DefaultUpOptions())
+			require.NoError(t, err)
+			assert.Contains(t, upSpec.Args, "--install-provider")
+
+			_, err = client.Global.PlanUpload("web", "/tmp/setup.sh", "", DefaultUploadOptions())
+			require.NoError(t, err)
+
+			_, err = client.Global.PlanWinrmConfig(DefaultWinrmConfigOptions())
+			require.NoError(t, err)
+
+			_, err = client.Box.PlanList()
+			require.NoError(t, err)
+
+			assert.Empty(t, executedArgs)
+		},
+	)
+
+	t.Run(
+		"with detected version not supporting `upload`, PlanUpload returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client, fakeOsExecutor := planTestClient(t)
+			client.version = &Version{Major: 2, Minor: 1, Patch: 5}
+			api := &globalAPI{client: client, osExecutor: fakeOsExecutor}
+
+			spec, err := api.PlanUpload("web", "/tmp/setup.sh", "", DefaultUploadOptions())
+			assert.Nil(t, spec)
+			assert.Equal(t, ErrorCodeUnsupportedVersion, stacktrace.GetCode(err))
+		},
+	)
+}
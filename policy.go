@@ -0,0 +1,158 @@
+package vagrant_go
+
+import (
+	"github.com/palantir/stacktrace"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Operation is an operation about to be executed, as given to `Policy`.
+type Operation struct {
+	// Name is the vagrant subcommand, e.g. `destroy` or `box list`.
+	Name string
+	// Targets are machine names, or regular expressions in format of `/web\d/`. All machines are targeted if empty.
+	Targets []string
+	// WorkingDirectory is the absolute path of the directory the operation is executed from. It's empty for box
+	// operations, which don't belong to a project.
+	WorkingDirectory string
+}
+
+// Policy is consulted before each operation. Returning an error denies the operation. The error is the reason, that's
+// returned by the operation with `ErrorCodePolicyDenied`.
+type Policy interface {
+	Check(operation *Operation) error
+}
+
+const defaultProtectionMarkerFile = ".vagrant-protected"
+
+// ProtectionPolicy denies protected operations in projects containing a marker file, or for machines matching a name
+// pattern.
+type ProtectionPolicy struct {
+	// MarkerFile protects projects containing a file with this name in their directory. It's ignored if empty.
+	MarkerFile string
+	// MachineNamePattern protects machines with a name matching it. It's ignored if nil.
+	// Operations targeting all machines, or a regular expression, are denied, since it's unknown which machines
+	// they target.
+	MachineNamePattern *regexp.Regexp
+	// Operations are names of the protected operations.
+	Operations []string
+}
+
+func DefaultProtectionPolicy() *ProtectionPolicy {
+	return &ProtectionPolicy{
+		MarkerFile:         defaultProtectionMarkerFile,
+		MachineNamePattern: nil,
+		Operations:         []string{"destroy"},
+	}
+}
+
+func (p *ProtectionPolicy) Check(operation *Operation) error {
+	if !contains(p.Operations, operation.Name) {
+		return nil
+	}
+
+	if len(p.MarkerFile) > 0 && len(operation.WorkingDirectory) > 0 {
+		markerPath := filepath.Join(operation.WorkingDirectory, p.MarkerFile)
+
+		_, err := os.Stat(markerPath)
+		if err == nil {
+			return stacktrace.NewError("project is protected by marker file `%s`", markerPath)
+		}
+
+		if !os.IsNotExist(err) {
+			return stacktrace.Propagate(err, "failed to check marker file `%s`", markerPath)
+		}
+	}
+
+	if p.MachineNamePattern == nil {
+		return nil
+	}
+
+	if len(operation.Targets) == 0 {
+		return stacktrace.NewError(
+			"all machines are targeted, which may include machines protected by pattern `%s`",
+			p.MachineNamePattern,
+		)
+	}
+
+	for _, target := range operation.Targets {
+		if strings.HasPrefix(target, "/") && strings.HasSuffix(target, "/") {
+			return stacktrace.NewError(
+				"target `%s` may include machines protected by pattern `%s`",
+				target,
+				p.MachineNamePattern,
+			)
+		}
+
+		if p.MachineNamePattern.MatchString(target) {
+			return stacktrace.NewError("machine `%s` is protected by pattern `%s`", target, p.MachineNamePattern)
+		}
+	}
+
+	return nil
+}
+
+// checkPolicy consults `Config.Policy`, if set, before executing operation `name` from within `workingDirectory`.
+func (c *Client) checkPolicy(osExecutor OsExecutor, name string, workingDirectory string, targets []string) error {
+	if c.Config.Policy == nil {
+		return nil
+	}
+
+	resolvedWorkingDirectory, err := resolveWorkingDirectory(osExecutor, workingDirectory)
+	if err != nil {
+		return err
+	}
+
+	return c.checkOperation(
+		&Operation{
+			Name:             name,
+			Targets:          targets,
+			WorkingDirectory: resolvedWorkingDirectory,
+		},
+	)
+}
+
+// checkBoxPolicy is like `checkPolicy`, but for box operations, which have no working directory.
+func (c *Client) checkBoxPolicy(name string) error {
+	if c.Config.Policy == nil {
+		return nil
+	}
+
+	return c.checkOperation(
+		&Operation{
+			Name:    name,
+			Targets: []string{},
+		},
+	)
+}
+
+func (c *Client) checkOperation(operation *Operation) error {
+	err := c.Config.Policy.Check(operation)
+	if err != nil {
+		return stacktrace.PropagateWithCode(
+			err,
+			ErrorCodePolicyDenied,
+			"operation `%s` in `%s` denied by policy",
+			operation.Name,
+			operation.WorkingDirectory,
+		)
+	}
+
+	return nil
+}
+
+// NOTE: Empty targets are given for optional machine names and are dropped.
+func (api *globalAPI) checkPolicy(name string, workingDirectory string, targets ...string) error {
+	//noinspection GoPreferNilSlice
+	nonEmptyTargets := []string{}
+
+	for _, target := range targets {
+		if len(target) > 0 {
+			nonEmptyTargets = append(nonEmptyTargets, target)
+		}
+	}
+
+	return api.client.checkPolicy(api.osExecutor, name, workingDirectory, nonEmptyTargets)
+}
@@ -0,0 +1,209 @@
+package vagrant_go
+
+import (
+	"context"
+	"errors"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+type fakePolicy struct {
+	mock.Mock
+}
+
+func (f *fakePolicy) Check(operation *Operation) error {
+	args := f.Called(operation)
+	return args.Error(0)
+}
+
+func TestProtectionPolicy_Check(t *testing.T) {
+	t.Run(
+		"with operation not being protected, it allows it",
+		func(t *testing.T) {
+			t.Parallel()
+
+			policy := DefaultProtectionPolicy()
+			policy.MachineNamePattern = regexp.MustCompile(`^dev-`)
+
+			err := policy.Check(&Operation{Name: "halt", WorkingDirectory: t.TempDir()})
+			assert.NoError(t, err)
+		},
+	)
+
+	t.Run(
+		"with project containing the marker file, it denies the operation",
+		func(t *testing.T) {
+			t.Parallel()
+
+			directory := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(directory, defaultProtectionMarkerFile), nil, 0o644))
+
+			err := DefaultProtectionPolicy().Check(&Operation{Name: "destroy", WorkingDirectory: directory})
+			assert.Error(t, err)
+		},
+	)
+
+	t.Run(
+		"with project not containing the marker file, it allows the operation",
+		func(t *testing.T) {
+			t.Parallel()
+
+			err := DefaultProtectionPolicy().Check(&Operation{Name: "destroy", WorkingDirectory: t.TempDir()})
+			assert.NoError(t, err)
+		},
+	)
+
+	t.Run(
+		"with target matching the name pattern, it denies the operation",
+		func(t *testing.T) {
+			t.Parallel()
+
+			policy := DefaultProtectionPolicy()
+			policy.MachineNamePattern = regexp.MustCompile(`^dev-`)
+
+			err := policy.Check(
+				&Operation{Name: "destroy", Targets: []string{"ci-1", "dev-alice"}, WorkingDirectory: t.TempDir()},
+			)
+			assert.Error(t, err)
+		},
+	)
+
+	t.Run(
+		"with targets not matching the name pattern, it allows the operation",
+		func(t *testing.T) {
+			t.Parallel()
+
+			policy := DefaultProtectionPolicy()
+			policy.MachineNamePattern = regexp.MustCompile(`^dev-`)
+
+			err := policy.Check(
+				&Operation{Name: "destroy", Targets: []string{"ci-1", "ci-2"}, WorkingDirectory: t.TempDir()},
+			)
+			assert.NoError(t, err)
+		},
+	)
+
+	t.Run(
+		"with name pattern and all machines or a regular expression targeted, it denies the operation",
+		func(t *testing.T) {
+			t.Parallel()
+
+			policy := DefaultProtectionPolicy()
+			policy.MachineNamePattern = regexp.MustCompile(`^dev-`)
+
+			err := policy.Check(&Operation{Name: "destroy", WorkingDirectory: t.TempDir()})
+			assert.Error(t, err)
+
+			err = policy.Check(&Operation{Name: "destroy", Targets: []string{`/ci-\d/`}, WorkingDirectory: t.TempDir()})
+			assert.Error(t, err)
+		},
+	)
+}
+
+func TestClient_Policy(t *testing.T) {
+	t.Run(
+		"with policy denying the operation, it returns an error with code and executes nothing",
+		func(t *testing.T) {
+			t.Parallel()
+
+			policy := &fakePolicy{}
+			policy.On(
+				"Check",
+				&Operation{Name: "destroy", Targets: []string{"web"}, WorkingDirectory: "/tmp/example"},
+			).Return(errors.New("fake error"))
+
+			client := emptyTestClient(t)
+			client.Config.Policy = policy
+			client.commandRunFunc = func(cmd string, args ...string) ([]byte, error) {
+				t.Fatalf("unexpected execution of `%s %v`", cmd, args)
+				return nil, nil
+			}
+
+			options := DefaultDestroyOptions()
+			options.WorkingDirectory = "/tmp/example"
+			options.Targets = []string{"web"}
+
+			result, err := client.Global.Destroy(options)
+			assert.Nil(t, result)
+			require.Error(t, err)
+			assert.Equal(t, ErrorCodePolicyDenied, stacktrace.GetCode(err))
+			assert.Contains(t, err.Error(), "fake error")
+		},
+	)
+
+	t.Run(
+		"with policy allowing the operation, it's given the operation with resolved working directory",
+		func(t *testing.T) {
+			t.Parallel()
+
+			policy := &fakePolicy{}
+			policy.On(
+				"Check",
+				&Operation{Name: "halt", Targets: []string{}, WorkingDirectory: "/tmp/anotherexample/example"},
+			).Return(nil)
+
+			client := projectTestClient(t)
+			client.Config.Policy = policy
+
+			options := DefaultHaltOptions()
+			options.WorkingDirectory = "example"
+
+			err := client.Project("example").global.Halt(options)
+			require.NoError(t, err)
+			policy.AssertExpectations(t)
+		},
+	)
+
+	t.Run(
+		"with policy and box operation, it's given the operation",
+		func(t *testing.T) {
+			t.Parallel()
+
+			policy := &fakePolicy{}
+			policy.On("Check", mock.MatchedBy(func(operation *Operation) bool {
+				return operation.Name == "box list"
+			})).Return(errors.New("fake error"))
+
+			fakeOsExecutor := &fakeOsExecutor{}
+			fakeOsExecutor.On("Getwd").Return("", errors.New("fake error"))
+
+			client := emptyTestClient(t)
+			client.osExecutor = fakeOsExecutor
+			client.Config.Policy = policy
+
+			boxes, err := client.Box.List()
+			assert.Nil(t, boxes)
+			assert.Equal(t, ErrorCodePolicyDenied, stacktrace.GetCode(err))
+			fakeOsExecutor.AssertNotCalled(t, "Getwd")
+		},
+	)
+
+	t.Run(
+		"with policy denying a box operation, Exec of the same box operation is denied",
+		func(t *testing.T) {
+			t.Parallel()
+
+			policy := &fakePolicy{}
+			policy.On("Check", mock.MatchedBy(func(operation *Operation) bool {
+				return operation.Name == "box add"
+			})).Return(errors.New("fake error"))
+
+			client := projectTestClient(t)
+			client.Config.Policy = policy
+			client.commandRunFunc = func(cmd string, args ...string) ([]byte, error) {
+				t.Fatalf("unexpected execution of `%s %v`", cmd, args)
+				return nil, nil
+			}
+
+			_, err := client.Exec(context.Background(), []string{"box", "add", "ubuntu/bionic64"}, DefaultExecOptions())
+			require.Error(t, err)
+			assert.Equal(t, ErrorCodePolicyDenied, stacktrace.GetCode(err))
+		},
+	)
+}
@@ -0,0 +1,136 @@
+package vagrant_go
+
+import (
+	"github.com/kevinburke/ssh_config"
+	"github.com/palantir/stacktrace"
+)
+
+// Project is a Vagrant environment bound to the directory containing its Vagrantfile.
+type Project struct {
+	Directory string
+	global    *globalAPI
+}
+
+// Machine is a handle to a single machine of a Project. All of its operations target only that machine.
+type Machine struct {
+	Name    string
+	project *Project
+}
+
+// Project returns a Project bound to `directory`. Nothing is executed until an operation is called.
+func (c *Client) Project(directory string) *Project {
+	return &Project{
+		Directory: directory,
+		global: &globalAPI{
+			client:     c,
+			osExecutor: c.osExecutor,
+		},
+	}
+}
+
+// Machines returns handles to all machines defined in the Vagrantfile of the Project.
+func (p *Project) Machines() ([]*Machine, error) {
+	statuses, err := p.global.Status(
+		&StatusOptions{
+			WorkingDirectory: p.Directory,
+		},
+	)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "failed to list machines of `%s`", p.Directory)
+	}
+
+	// NOTE: Use 0 element slice in case there's nothing to return
+	//noinspection GoPreferNilSlice
+	machines := []*Machine{}
+
+	for _, status := range statuses {
+		machines = append(machines, p.Machine(status.Name))
+	}
+
+	return machines, nil
+}
+
+// Machine returns a handle to machine `name`. It's not checked whether the machine is defined in the Vagrantfile.
+func (p *Project) Machine(name string) *Machine {
+	return &Machine{
+		Name:    name,
+		project: p,
+	}
+}
+
+func (m *Machine) Up(options *UpOptions) (*UpResult, error) {
+	machineOptions := *options
+	machineOptions.WorkingDirectory = m.project.Directory
+	machineOptions.Targets = []string{m.Name}
+
+	return m.project.global.Up(&machineOptions)
+}
+
+func (m *Machine) Halt(options *HaltOptions) error {
+	machineOptions := *options
+	machineOptions.WorkingDirectory = m.project.Directory
+	machineOptions.Targets = []string{m.Name}
+
+	return m.project.global.Halt(&machineOptions)
+}
+
+func (m *Machine) Destroy(options *DestroyOptions) (*DestroyResult, error) {
+	machineOptions := *options
+	machineOptions.WorkingDirectory = m.project.Directory
+	machineOptions.Targets = []string{m.Name}
+
+	return m.project.global.Destroy(&machineOptions)
+}
+
+func (m *Machine) Reload(options *ReloadOptions) (*ReloadResult, error) {
+	machineOptions := *options
+	machineOptions.WorkingDirectory = m.project.Directory
+	machineOptions.Targets = []string{m.Name}
+
+	return m.project.global.Reload(&machineOptions)
+}
+
+func (m *Machine) Provision(options *ProvisionOptions) (*ProvisionResult, error) {
+	machineOptions := *options
+	machineOptions.WorkingDirectory = m.project.Directory
+	machineOptions.Targets = []string{m.Name}
+
+	return m.project.global.Provision(&machineOptions)
+}
+
+func (m *Machine) Status() (*MachineStatus, error) {
+	statuses, err := m.project.global.Status(
+		&StatusOptions{
+			WorkingDirectory: m.project.Directory,
+			Targets:          []string{m.Name},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, status := range statuses {
+		if status.Name == m.Name {
+			return status, nil
+		}
+	}
+
+	return nil, stacktrace.NewError("no status reported for machine `%s`", m.Name)
+}
+
+func (m *Machine) SshConfig(options *SshConfigOptions) (*ssh_config.Config, error) {
+	machineOptions := *options
+	machineOptions.WorkingDirectory = m.project.Directory
+
+	return m.project.global.sshConfig(&machineOptions, m.Name)
+}
+
+func (m *Machine) SshExec(command string) (string, error) {
+	return m.project.global.SshExec(
+		m.Name,
+		command,
+		&SshExecOptions{
+			WorkingDirectory: m.project.Directory,
+		},
+	)
+}
@@ -0,0 +1,200 @@
+package vagrant_go
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func projectTestClient(t *testing.T) *Client {
+	fakeOsExecutor := &fakeOsExecutor{}
+	fakeOsExecutor.On("Getwd").Return("/tmp/anotherexample", nil)
+	fakeOsExecutor.On("Chdir", mock.Anything).Return(nil)
+
+	client := emptyTestClient(t)
+	client.osExecutor = fakeOsExecutor
+
+	return client
+}
+
+func TestClient_Project(t *testing.T) {
+	t.Parallel()
+
+	client := emptyTestClient(t)
+
+	project := client.Project("/tmp/example")
+	require.NotNil(t, project)
+	assert.Equal(t, "/tmp/example", project.Directory)
+
+	machine := project.Machine("web")
+	assert.Equal(t, "web", machine.Name)
+}
+
+func TestProject_Machines(t *testing.T) {
+	t.Run(
+		"with 2 machines in the Vagrantfile, it returns handles for both",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := projectTestClient(t)
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{"--machine-readable", "status"}, args)
+
+				output := `
+1546430404,web,provider-name,libvirt
+1546430404,web,state,running
+1546430404,db,provider-name,libvirt
+1546430404,db,state,not_created
+`
+				return []byte(output), nil
+			}
+
+			project := client.Project("/tmp/example")
+
+			machines, err := project.Machines()
+			require.NoError(t, err)
+			require.Len(t, machines, 2)
+
+			assert.Equal(t, "web", machines[0].Name)
+			assert.Equal(t, "db", machines[1].Name)
+			client.osExecutor.(*fakeOsExecutor).AssertCalled(t, "Chdir", "/tmp/example")
+		},
+	)
+
+	t.Run(
+		"with command execution returning an error, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := projectTestClient(t)
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				return []byte{}, errors.New("fake error")
+			}
+
+			machines, err := client.Project("/tmp/example").Machines()
+			assert.Nil(t, machines)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "failed to list machines of `/tmp/example`")
+		},
+	)
+}
+
+func TestMachine(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectedArgs []string
+		call         func(machine *Machine) error
+	}{
+		{
+			name:         "Up",
+			expectedArgs: []string{"--machine-readable", "up", "--provision", "--destroy-on-error", "--parallel", "--install-provider", "web"},
+			call: func(machine *Machine) error {
+				_, err := machine.Up(DefaultUpOptions())
+				return err
+			},
+		},
+		{
+			name:         "Halt",
+			expectedArgs: []string{"--machine-readable", "halt", "web"},
+			call: func(machine *Machine) error {
+				return machine.Halt(DefaultHaltOptions())
+			},
+		},
+		{
+			name:         "Destroy",
+			expectedArgs: []string{"--machine-readable", "destroy", "--force", "--parallel", "web"},
+			call: func(machine *Machine) error {
+				_, err := machine.Destroy(DefaultDestroyOptions())
+				return err
+			},
+		},
+		{
+			name:         "SshConfig",
+			expectedArgs: []string{"--machine-readable", "ssh-config", "web"},
+			call: func(machine *Machine) error {
+				_, err := machine.SshConfig(DefaultSshConfigOptions())
+				return err
+			},
+		},
+		{
+			name:         "SshExec",
+			expectedArgs: []string{"ssh", "--command", "uptime", "web"},
+			call: func(machine *Machine) error {
+				_, err := machine.SshExec("uptime")
+				return err
+			},
+		},
+	}
+
+	for _, subTest := range tests {
+		subTest := subTest
+
+		t.Run(
+			"with "+subTest.name+", it executes command in project directory targeting only the machine",
+			func(t *testing.T) {
+				t.Parallel()
+
+				client := projectTestClient(t)
+				client.version = testVersion
+				isCommandRunCalled := false
+
+				client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+					assert.Equal(t, subTest.expectedArgs, args)
+					isCommandRunCalled = true
+					return []byte{}, nil
+				}
+
+				machine := client.Project("/tmp/example").Machine("web")
+
+				err := subTest.call(machine)
+				require.NoError(t, err)
+
+				assert.True(t, isCommandRunCalled)
+				client.osExecutor.(*fakeOsExecutor).AssertCalled(t, "Chdir", "/tmp/example")
+			},
+		)
+	}
+}
+
+func TestMachine_Status(t *testing.T) {
+	t.Run(
+		"with status reported for the machine, it returns it",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := projectTestClient(t)
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{"--machine-readable", "status", "web"}, args)
+
+				output := `
+1546430404,web,provider-name,virtualbox
+1546430404,web,state,poweroff
+`
+				return []byte(output), nil
+			}
+
+			status, err := client.Project("/tmp/example").Machine("web").Status()
+			require.NoError(t, err)
+
+			assert.Equal(t, "web", status.Name)
+			assert.Equal(t, "virtualbox", status.Provider)
+			assert.Equal(t, "poweroff", status.State)
+		},
+	)
+
+	t.Run(
+		"with no status reported for the machine, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := projectTestClient(t)
+
+			status, err := client.Project("/tmp/example").Machine("web").Status()
+			assert.Nil(t, status)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "no status reported for machine `web`")
+		},
+	)
+}
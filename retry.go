@@ -0,0 +1,83 @@
+package vagrant_go
+
+import (
+	"math"
+	"time"
+)
+
+// DefaultRetryableErrorClasses are classes of errors reported by Vagrant, that are transient.
+var DefaultRetryableErrorClasses = []string{
+	// NOTE: Reported when another process is running an action on the same machine
+	"Vagrant::Errors::MachineLocked",
+	"Vagrant::Errors::EnvironmentLockedError",
+	// NOTE: Reported when box downloads fail or time out
+	"Vagrant::Errors::DownloaderError",
+	"Vagrant::Errors::BoxMetadataDownloadError",
+}
+
+// RetryPolicy retries operations, that are safe to repeat, when they fail with a transient error. These are
+// `Box.List`, `Box.Add`, `Status`, `SshConfig`, `SshConnectionInfo` and `WinrmConfig`, plus `Up` when
+// `UpOptions.Retry` is set.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the delay before the second attempt. It's multiplied by `Multiplier` for each further attempt.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter is the fraction of each delay, that's randomly subtracted from it. It's between 0 and 1.
+	Jitter float64
+	// IsRetryable returns whether a failure with `errorClass`, as reported by Vagrant, is retried. The class is empty,
+	// if none is reported. Classes in `DefaultRetryableErrorClasses` are retried, if nil.
+	IsRetryable func(errorClass string) bool
+}
+
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 2 * time.Second,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		IsRetryable:    nil,
+	}
+}
+
+func (p *RetryPolicy) isRetryable(errorClass string) bool {
+	if p.IsRetryable != nil {
+		return p.IsRetryable(errorClass)
+	}
+
+	return contains(DefaultRetryableErrorClasses, errorClass)
+}
+
+// backoff returns the delay after failed attempt `attempt`, counting from 1. `random` is between 0 and 1.
+func (p *RetryPolicy) backoff(attempt int, random float64) time.Duration {
+	delay := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt-1))
+
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+
+	return time.Duration(delay * (1 - p.Jitter*random))
+}
+
+// withRetry calls `fn` until it succeeds, fails with an error that's not retryable, or `Config.Retry` attempts are
+// exhausted. The output lines and error of the last attempt are returned.
+func (c *Client) withRetry(fn func() ([]*vagrantOutputLine, error)) ([]*vagrantOutputLine, error) {
+	policy := c.Config.Retry
+
+	for attempt := 1; ; attempt++ {
+		outputLines, err := fn()
+		if err == nil || policy == nil || attempt >= policy.MaxAttempts {
+			return outputLines, err
+		}
+
+		errorClass, _, _ := findErrorExit(outputLines)
+		if !policy.isRetryable(errorClass) {
+			return outputLines, err
+		}
+
+		c.sleepFunc(policy.backoff(attempt, c.randomFunc()))
+	}
+}
@@ -0,0 +1,167 @@
+package vagrant_go
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const machineLockedOutput = `1546430404,,error-exit,Vagrant::Errors::MachineLocked,Vagrant can't use the requested machine because it's locked!`
+
+func retryTestClient(t *testing.T, outputs []string) (*Client, *int, *[]time.Duration) {
+	client := emptyTestClient(t)
+	client.Config.Retry = DefaultRetryPolicy()
+	client.randomFunc = func() float64 {
+		return 0.5
+	}
+
+	sleeps := []time.Duration{}
+	client.sleepFunc = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+	}
+
+	calls := 0
+	client.commandRunFunc = func(cmd string, args ...string) ([]byte, error) {
+		output := outputs[calls]
+		calls++
+
+		if output == machineLockedOutput {
+			return []byte(output), errors.New("fake error")
+		}
+
+		return []byte(output), nil
+	}
+
+	return client, &calls, &sleeps
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	t.Run(
+		"with no jitter, it returns exponentially growing delays up to the maximum",
+		func(t *testing.T) {
+			t.Parallel()
+
+			policy := DefaultRetryPolicy()
+			policy.Jitter = 0
+
+			assert.Equal(t, 2*time.Second, policy.backoff(1, 0.5))
+			assert.Equal(t, 4*time.Second, policy.backoff(2, 0.5))
+			assert.Equal(t, 8*time.Second, policy.backoff(3, 0.5))
+			assert.Equal(t, 30*time.Second, policy.backoff(10, 0.5))
+		},
+	)
+
+	t.Run(
+		"with jitter, it subtracts the random fraction of the delay",
+		func(t *testing.T) {
+			t.Parallel()
+
+			policy := DefaultRetryPolicy()
+
+			assert.Equal(t, 2*time.Second, policy.backoff(1, 0))
+			assert.Equal(t, 1800*time.Millisecond, policy.backoff(1, 0.5))
+			assert.Equal(t, 1600*time.Millisecond, policy.backoff(1, 1))
+		},
+	)
+}
+
+func TestClient_withRetry(t *testing.T) {
+	t.Run(
+		"with `Status` failing with a transient error once, it retries and returns statuses",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client, calls, sleeps := retryTestClient(t, []string{
+				machineLockedOutput,
+				"1546430404,default,state,running",
+			})
+
+			statuses, err := client.Global.Status(DefaultStatusOptions())
+			require.NoError(t, err)
+			require.Len(t, statuses, 1)
+			assert.Equal(t, 2, *calls)
+			assert.Equal(t, []time.Duration{1800 * time.Millisecond}, *sleeps)
+		},
+	)
+
+	t.Run(
+		"with `Status` failing with a transient error on each attempt, it gives up after max attempts",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client, calls, sleeps := retryTestClient(t, []string{
+				machineLockedOutput,
+				machineLockedOutput,
+				machineLockedOutput,
+			})
+
+			_, err := client.Global.Status(DefaultStatusOptions())
+			assert.Error(t, err)
+			assert.Equal(t, 3, *calls)
+			assert.Len(t, *sleeps, 2)
+		},
+	)
+
+	t.Run(
+		"with `Status` failing with an error that's not retryable, it returns it without retrying",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client, calls, _ := retryTestClient(t, []string{machineLockedOutput})
+			client.Config.Retry.IsRetryable = func(errorClass string) bool {
+				return errorClass == "Vagrant::Errors::DownloaderError"
+			}
+
+			_, err := client.Global.Status(DefaultStatusOptions())
+			assert.Error(t, err)
+			assert.Equal(t, 1, *calls)
+		},
+	)
+
+	t.Run(
+		"with `Up` failing with a transient error and no opt in, it returns it without retrying",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client, calls, _ := retryTestClient(t, []string{machineLockedOutput})
+			client.version = testVersion
+
+			_, err := client.Global.Up(DefaultUpOptions())
+			assert.Error(t, err)
+			assert.Equal(t, 1, *calls)
+		},
+	)
+
+	t.Run(
+		"with `Up` failing with a transient error and opt in, it retries",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client, calls, _ := retryTestClient(t, []string{machineLockedOutput, ""})
+			client.version = testVersion
+
+			options := DefaultUpOptions()
+			options.Retry = true
+
+			_, err := client.Global.Up(options)
+			require.NoError(t, err)
+			assert.Equal(t, 2, *calls)
+		},
+	)
+
+	t.Run(
+		"with no retry policy, it returns the error without retrying",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client, calls, _ := retryTestClient(t, []string{machineLockedOutput})
+			client.Config.Retry = nil
+
+			_, err := client.Global.Status(DefaultStatusOptions())
+			assert.Error(t, err)
+			assert.Equal(t, 1, *calls)
+		},
+	)
+}
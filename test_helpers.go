@@ -2,6 +2,7 @@ package vagrant_go
 
 import (
 	"github.com/stretchr/testify/mock"
+	"os"
 	"testing"
 )
 
@@ -48,3 +49,34 @@ func (f *fakeOsExecutor) Chdir(dir string) error {
 	args := f.Called(dir)
 	return args.Error(0)
 }
+
+func (f *fakeOsExecutor) Stat(name string) (os.FileInfo, error) {
+	args := f.Called(name)
+
+	fileInfo, _ := args.Get(0).(os.FileInfo)
+	return fileInfo, args.Error(1)
+}
+
+func (f *fakeOsExecutor) Environ() []string {
+	args := f.Called()
+
+	env, _ := args.Get(0).([]string)
+	return env
+}
+
+func (f *fakeOsExecutor) Getenv(key string) string {
+	args := f.Called(key)
+	return args.String(0)
+}
+
+type fakeFileInfo struct {
+	os.FileInfo
+	isDir bool
+}
+
+func (f *fakeFileInfo) IsDir() bool {
+	return f.isDir
+}
+
+// testVersion is set as the installed version by tests of operations gated by version, that don't test the gating.
+var testVersion = &Version{Major: 2, Minor: 2, Patch: 3}
@@ -0,0 +1,179 @@
+package vagrant_go
+
+import (
+	"strings"
+	"time"
+)
+
+// TimelinePhaseName is the name of a phase of a machine in a `Timeline`.
+type TimelinePhaseName string
+
+const (
+	TimelinePhaseImport        TimelinePhaseName = "import"
+	TimelinePhaseBoot          TimelinePhaseName = "boot"
+	TimelinePhaseWaitForSsh    TimelinePhaseName = "wait_for_ssh"
+	TimelinePhaseSyncedFolders TimelinePhaseName = "synced_folders"
+	// TimelinePhaseProvision is a phase per provisioner that ran.
+	TimelinePhaseProvision TimelinePhaseName = "provision"
+)
+
+// Timeline lists phases of each machine of an operation. Times have a resolution of one second.
+type Timeline struct {
+	Machines []*MachineTimeline `json:"machines"`
+}
+
+// MachineTimeline lists phases of a single machine, in order.
+type MachineTimeline struct {
+	Name      string           `json:"name"`
+	StartTime time.Time        `json:"start_time"`
+	EndTime   time.Time        `json:"end_time"`
+	Phases    []*TimelinePhase `json:"phases"`
+}
+
+type TimelinePhase struct {
+	Name TimelinePhaseName `json:"name"`
+	// Provisioner is the name of the provisioner of a `provision` phase.
+	Provisioner string    `json:"provisioner,omitempty"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+}
+
+// NOTE: Phases are reported in `ui` lines only. These are the messages starting them, for the bundled providers and
+// libvirt.
+var timelinePhaseMessages = []struct {
+	prefix string
+	phase  TimelinePhaseName
+}{
+	{"Importing base box", TimelinePhaseImport},
+	{"Creating image (snapshot of base box volume)", TimelinePhaseImport},
+	{"Cloning VM", TimelinePhaseImport},
+	{"Booting VM", TimelinePhaseBoot},
+	{"Starting domain", TimelinePhaseBoot},
+	{"Waiting for machine to boot", TimelinePhaseWaitForSsh},
+	{"Waiting for domain to get an IP address", TimelinePhaseWaitForSsh},
+	{"Waiting for SSH to become available", TimelinePhaseWaitForSsh},
+	{"Mounting shared folders", TimelinePhaseSyncedFolders},
+	{"Rsyncing folder", TimelinePhaseSyncedFolders},
+	{"Installing rsync", TimelinePhaseSyncedFolders},
+	{"Exporting NFS shared folders", TimelinePhaseSyncedFolders},
+	{"Mounting NFS shared folders", TimelinePhaseSyncedFolders},
+}
+
+// NOTE: This message ends the `wait_for_ssh` phase, without starting another one.
+const machineReadyMessage = "Machine booted and ready!"
+
+// timelineFromOutputLines returns a timeline of each machine reporting `action`. A machine's timeline lasts from start
+// to end of the action, or to its last line, when the action did not end. A phase lasts until the next one starts.
+func timelineFromOutputLines(outputLines []*vagrantOutputLine, action string) *Timeline {
+	timeline := &Timeline{
+		Machines: []*MachineTimeline{},
+	}
+	machinesByName := map[string]*MachineTimeline{}
+	currentPhases := map[string]*TimelinePhase{}
+	endedMachines := map[string]bool{}
+
+	for _, line := range outputLines {
+		if len(line.target) == 0 || len(line.data) < 2 {
+			continue
+		}
+
+		timestamp := parseOutputLineTimestamp(line.timestamp)
+
+		machine, ok := machinesByName[line.target]
+		if !ok {
+			if line.kind != "action" || line.data[0] != action || line.data[1] != "start" {
+				continue
+			}
+
+			machine = &MachineTimeline{
+				Name:      line.target,
+				StartTime: timestamp,
+				Phases:    []*TimelinePhase{},
+			}
+			machinesByName[line.target] = machine
+			timeline.Machines = append(timeline.Machines, machine)
+		}
+
+		if endedMachines[line.target] {
+			continue
+		}
+
+		machine.EndTime = timestamp
+
+		if line.kind == "action" && line.data[0] == action && line.data[1] == "end" {
+			endedMachines[line.target] = true
+			endPhase(currentPhases, line.target, timestamp)
+			continue
+		}
+
+		if line.kind != "ui" {
+			continue
+		}
+
+		message := unescapeMachineReadable(line.data[1])
+
+		if strings.Contains(message, machineReadyMessage) {
+			endPhase(currentPhases, line.target, timestamp)
+			continue
+		}
+
+		phase := phaseFromMessage(message)
+		if phase == nil {
+			continue
+		}
+
+		current := currentPhases[line.target]
+		if current != nil && current.Name == phase.Name && current.Provisioner == phase.Provisioner {
+			continue
+		}
+
+		endPhase(currentPhases, line.target, timestamp)
+
+		phase.StartTime = timestamp
+		currentPhases[line.target] = phase
+		machine.Phases = append(machine.Phases, phase)
+	}
+
+	for name, phase := range currentPhases {
+		phase.EndTime = machinesByName[name].EndTime
+	}
+
+	return timeline
+}
+
+func endPhase(currentPhases map[string]*TimelinePhase, machine string, timestamp time.Time) {
+	phase, ok := currentPhases[machine]
+	if !ok {
+		return
+	}
+
+	phase.EndTime = timestamp
+	delete(currentPhases, machine)
+}
+
+// phaseFromMessage returns the phase started by `message` of a `ui` line, in format of `==> default: Booting VM...`.
+func phaseFromMessage(message string) *TimelinePhase {
+	provisioner, found := provisionerFromMessage(message)
+	if found {
+		return &TimelinePhase{
+			Name:        TimelinePhaseProvision,
+			Provisioner: provisioner,
+		}
+	}
+
+	// NOTE: Strip the `==> default: ` prefix, if any
+	index := strings.Index(message, ": ")
+	if index >= 0 && strings.HasPrefix(message, "==>") {
+		message = message[index+2:]
+	}
+
+	for _, phaseMessage := range timelinePhaseMessages {
+		if strings.HasPrefix(strings.TrimSpace(message), phaseMessage.prefix) {
+			return &TimelinePhase{
+				Name: phaseMessage.phase,
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package vagrant_go
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimelineFromOutputLines(t *testing.T) {
+	t.Run(
+		"with output of `vagrant up`, it returns phases per machine",
+		func(t *testing.T) {
+			t.Parallel()
+
+			outputLines, err := scanOutputLines(strings.NewReader(`
+1546430400,default,metadata,provider,virtualbox
+1546430400,default,action,up,start
+1546430401,default,ui,info,Importing base box 'debian/buster64'...
+1546430430,default,ui,info,Booting VM...
+1546430440,default,ui,info,Waiting for machine to boot. This may take a few minutes...
+1546430500,default,ui,info,Machine booted and ready!
+1546430510,default,ui,info,Mounting shared folders...
+1546430515,default,ui,info,Running provisioner: shell...
+1546430600,default,ui,info,Running provisioner: setup (ansible)...
+1546430700,default,action,up,end
+`), false)
+			require.NoError(t, err)
+
+			timeline := timelineFromOutputLines(outputLines, "up")
+			require.Len(t, timeline.Machines, 1)
+
+			machine := timeline.Machines[0]
+			assert.Equal(t, "default", machine.Name)
+			assert.Equal(t, time.Unix(1546430400, 0), machine.StartTime)
+			assert.Equal(t, time.Unix(1546430700, 0), machine.EndTime)
+
+			assert.Equal(t, []*TimelinePhase{
+				{Name: TimelinePhaseImport, StartTime: time.Unix(1546430401, 0), EndTime: time.Unix(1546430430, 0)},
+				{Name: TimelinePhaseBoot, StartTime: time.Unix(1546430430, 0), EndTime: time.Unix(1546430440, 0)},
+				{Name: TimelinePhaseWaitForSsh, StartTime: time.Unix(1546430440, 0), EndTime: time.Unix(1546430500, 0)},
+				{Name: TimelinePhaseSyncedFolders, StartTime: time.Unix(1546430510, 0), EndTime: time.Unix(1546430515, 0)},
+				{
+					Name:        TimelinePhaseProvision,
+					Provisioner: "shell",
+					StartTime:   time.Unix(1546430515, 0),
+					EndTime:     time.Unix(1546430600, 0),
+				},
+				{
+					Name:        TimelinePhaseProvision,
+					Provisioner: "setup (ansible)",
+					StartTime:   time.Unix(1546430600, 0),
+					EndTime:     time.Unix(1546430700, 0),
+				},
+			}, machine.Phases)
+		},
+	)
+
+	t.Run(
+		"with action not ending, it ends the machine and its last phase at its last line",
+		func(t *testing.T) {
+			t.Parallel()
+
+			outputLines, err := scanOutputLines(strings.NewReader(`
+1546430400,web,action,up,start
+1546430410,web,ui,info,==> web: Rsyncing folder: /src/ => /vagrant
+1546430420,web,ui,info,==> web: Rsyncing folder: /data/ => /data
+1546430430,web,ui,error,Something went wrong
+1546430400,db,metadata,provider,libvirt
+`), false)
+			require.NoError(t, err)
+
+			timeline := timelineFromOutputLines(outputLines, "up")
+			require.Len(t, timeline.Machines, 1)
+
+			machine := timeline.Machines[0]
+			assert.Equal(t, "web", machine.Name)
+			assert.Equal(t, time.Unix(1546430430, 0), machine.EndTime)
+			require.Len(t, machine.Phases, 1)
+			assert.Equal(t, TimelinePhaseSyncedFolders, machine.Phases[0].Name)
+			assert.Equal(t, time.Unix(1546430410, 0), machine.Phases[0].StartTime)
+			assert.Equal(t, time.Unix(1546430430, 0), machine.Phases[0].EndTime)
+		},
+	)
+}
+
+func TestTimeline_JSON(t *testing.T) {
+	t.Parallel()
+
+	timeline := &Timeline{
+		Machines: []*MachineTimeline{
+			{
+				Name:      "default",
+				StartTime: time.Unix(1546430400, 0).UTC(),
+				EndTime:   time.Unix(1546430460, 0).UTC(),
+				Phases: []*TimelinePhase{
+					{
+						Name:      TimelinePhaseBoot,
+						StartTime: time.Unix(1546430400, 0).UTC(),
+						EndTime:   time.Unix(1546430460, 0).UTC(),
+					},
+				},
+			},
+		},
+	}
+
+	encoded, err := json.Marshal(timeline)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"machines": [{
+			"name": "default",
+			"start_time": "2019-01-02T12:00:00Z",
+			"end_time": "2019-01-02T12:01:00Z",
+			"phases": [{"name": "boot", "start_time": "2019-01-02T12:00:00Z", "end_time": "2019-01-02T12:01:00Z"}]
+		}]
+	}`, string(encoded))
+
+	decoded := &Timeline{}
+	require.NoError(t, json.Unmarshal(encoded, decoded))
+	assert.Equal(t, timeline, decoded)
+}
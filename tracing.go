@@ -0,0 +1,167 @@
+package vagrant_go
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Span is a finished span of a vagrant invocation, or of a machine or phase within it.
+type Span struct {
+	Name       string
+	StartTime  time.Time
+	EndTime    time.Time
+	Attributes map[string]string
+	Children   []*Span
+}
+
+func (s *Span) Duration() time.Duration {
+	return s.EndTime.Sub(s.StartTime)
+}
+
+// Tracer gets the root span of every vagrant invocation made through `Client`, after it's done.
+// It's called concurrently, when the `Client` is used concurrently.
+type Tracer interface {
+	RecordSpan(span *Span)
+}
+
+// tracingMiddleware records a root span per invocation, with child spans per machine and per phase of each machine.
+// Phases are the ones of `Timeline`, that are reported in `ui` lines. Their timestamps have a resolution of one second.
+func tracingMiddleware(tracer Tracer) Middleware {
+	return func(next CommandHandler) CommandHandler {
+		return func(ctx context.Context, spec *CommandSpec) ([]byte, error) {
+			startedAt := time.Now()
+
+			output, err := next(ctx, spec)
+
+			endedAt := time.Now()
+			operation := operationName(spec.Args)
+
+			span := &Span{
+				Name:      operation,
+				StartTime: startedAt,
+				EndTime:   endedAt,
+				Attributes: map[string]string{
+					"vagrant.args":              strings.Join(spec.Args, " "),
+					"vagrant.working_directory": spec.WorkingDirectory,
+				},
+			}
+
+			outputLines, _ := parseOutputLines(ctx, output)
+			span.Children = machineSpansFromOutputLines(outputLines, operation, endedAt)
+
+			if err != nil {
+				span.Attributes["error"] = err.Error()
+
+				errorClass, _, found := findErrorExit(outputLines)
+				if found {
+					span.Attributes["error.class"] = errorClass
+				}
+			}
+
+			tracer.RecordSpan(span)
+
+			return output, err
+		}
+	}
+}
+
+// machineSpansFromOutputLines returns a span per machine reporting `operation`, in order of appearance, with a child
+// span per phase of its `Timeline`. A machine span lasts from start to end of the operation. Machines that did not
+// end the operation, and their last phase, end at `endedAt`.
+func machineSpansFromOutputLines(outputLines []*vagrantOutputLine, operation string, endedAt time.Time) []*Span {
+	// NOTE: Use 0 element slice in case there's nothing to return
+	//noinspection GoPreferNilSlice
+	machineSpans := []*Span{}
+	providers := map[string]string{}
+	endedMachines := map[string]bool{}
+
+	for _, line := range outputLines {
+		if len(line.target) == 0 || len(line.data) < 2 {
+			continue
+		}
+
+		switch {
+		case line.kind == "metadata" && line.data[0] == "provider":
+			providers[line.target] = line.data[1]
+		case line.kind == "action" && line.data[0] == operation && line.data[1] == "end":
+			endedMachines[line.target] = true
+		}
+	}
+
+	for _, machine := range timelineFromOutputLines(outputLines, operation).Machines {
+		ended := endedMachines[machine.Name]
+
+		machineSpan := &Span{
+			Name:      machine.Name,
+			StartTime: machine.StartTime,
+			EndTime:   machine.EndTime,
+			Attributes: map[string]string{
+				"vagrant.machine":  machine.Name,
+				"vagrant.provider": providers[machine.Name],
+			},
+			Children: []*Span{},
+		}
+
+		if !ended {
+			machineSpan.EndTime = endedAt
+		}
+
+		for i, phase := range machine.Phases {
+			completed := ended || i < len(machine.Phases)-1
+
+			phaseSpan := &Span{
+				Name:      string(phase.Name),
+				StartTime: phase.StartTime,
+				EndTime:   phase.EndTime,
+				Attributes: map[string]string{
+					"vagrant.machine":         machine.Name,
+					"vagrant.phase.completed": strconv.FormatBool(completed),
+				},
+			}
+
+			if !completed {
+				phaseSpan.EndTime = endedAt
+			}
+
+			if len(phase.Provisioner) > 0 {
+				phaseSpan.Attributes["vagrant.provisioner"] = phase.Provisioner
+			}
+
+			machineSpan.Children = append(machineSpan.Children, phaseSpan)
+		}
+
+		machineSpans = append(machineSpans, machineSpan)
+	}
+
+	return machineSpans
+}
+
+// InMemorySpanRecorder is a `Tracer` keeping all recorded spans in memory, e.g. for tests.
+type InMemorySpanRecorder struct {
+	mutex sync.Mutex
+	spans []*Span
+}
+
+func NewInMemorySpanRecorder() *InMemorySpanRecorder {
+	return &InMemorySpanRecorder{
+		spans: []*Span{},
+	}
+}
+
+func (r *InMemorySpanRecorder) RecordSpan(span *Span) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.spans = append(r.spans, span)
+}
+
+// Spans returns the recorded root spans, in order of recording.
+func (r *InMemorySpanRecorder) Spans() []*Span {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]*Span{}, r.spans...)
+}
@@ -0,0 +1,95 @@
+package vagrant_go
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestClient_Tracer(t *testing.T) {
+	t.Run(
+		"with `Up` of 2 machines, it records a span with child spans per machine and phase",
+		func(t *testing.T) {
+			t.Parallel()
+
+			recorder := NewInMemorySpanRecorder()
+
+			client := emptyTestClient(t)
+			client.version = testVersion
+			client.middleware = []Middleware{tracingMiddleware(recorder)}
+			client.commandRunFunc = func(cmd string, args ...string) ([]byte, error) {
+				return []byte(`
+1546430400,web,metadata,provider,libvirt
+1546430400,db,metadata,provider,libvirt
+1546430400,web,action,up,start
+1546430400,web,ui,info,Bringing machine 'web' up with 'libvirt' provider...
+1546430410,web,ui,info,==> web: Starting domain.
+1546430420,web,ui,info,==> web: Waiting for domain to get an IP address...
+1546430470,web,ui,info,==> web: Machine booted and ready!
+1546430470,web,ui,info,==> web: Running provisioner: shell...
+1546430640,web,action,up,end
+1546430400,db,action,up,start
+1546430410,db,ui,info,==> db: Starting domain.
+1546430420,db,ui,info,==> db: Running provisioner: setup (ansible)...
+`), errors.New("fake error")
+			}
+
+			_, err := client.Global.Up(DefaultUpOptions())
+			require.Error(t, err)
+
+			spans := recorder.Spans()
+			require.Len(t, spans, 1)
+
+			span := spans[0]
+			assert.Equal(t, "up", span.Name)
+			assert.Equal(t, "fake error", span.Attributes["error"])
+			require.Len(t, span.Children, 2)
+
+			web := span.Children[0]
+			assert.Equal(t, "web", web.Name)
+			assert.Equal(t, "libvirt", web.Attributes["vagrant.provider"])
+			assert.Equal(t, 4*time.Minute, web.Duration())
+			require.Len(t, web.Children, 3)
+			assert.Equal(t, "boot", web.Children[0].Name)
+			assert.Equal(t, 10*time.Second, web.Children[0].Duration())
+			assert.Equal(t, "wait_for_ssh", web.Children[1].Name)
+			assert.Equal(t, 50*time.Second, web.Children[1].Duration())
+			assert.Equal(t, "provision", web.Children[2].Name)
+			assert.Equal(t, "shell", web.Children[2].Attributes["vagrant.provisioner"])
+			assert.Equal(t, 170*time.Second, web.Children[2].Duration())
+			assert.Equal(t, "true", web.Children[2].Attributes["vagrant.phase.completed"])
+
+			db := span.Children[1]
+			assert.Equal(t, "db", db.Name)
+			assert.Equal(t, span.EndTime, db.EndTime)
+			require.Len(t, db.Children, 2)
+			assert.Equal(t, "true", db.Children[0].Attributes["vagrant.phase.completed"])
+			assert.Equal(t, "provision", db.Children[1].Name)
+			assert.Equal(t, span.EndTime, db.Children[1].EndTime)
+			assert.Equal(t, "false", db.Children[1].Attributes["vagrant.phase.completed"])
+		},
+	)
+
+	t.Run(
+		"with tracer in config, it records a span per invocation",
+		func(t *testing.T) {
+			t.Parallel()
+
+			recorder := NewInMemorySpanRecorder()
+
+			client, err := NewClient(&Config{Tracer: recorder}, emptyCommandRunFunc, emptyLookPathFunc)
+			require.NoError(t, err)
+
+			_, err = client.Box.List()
+			require.NoError(t, err)
+
+			spans := recorder.Spans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, "box list", spans[0].Name)
+			assert.Equal(t, "--machine-readable box list", spans[0].Attributes["vagrant.args"])
+			assert.Empty(t, spans[0].Children)
+		},
+	)
+}
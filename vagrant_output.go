@@ -2,6 +2,7 @@ package vagrant_go
 
 import (
 	"strings"
+	"time"
 )
 
 var ignoredOutputLines = []string{"metadata", "ui", "action"}
@@ -15,36 +16,116 @@ type vagrantOutputLine struct {
 	data      []string
 }
 
-func vagrantOutputLineFromString(str string) *vagrantOutputLine {
-	trimmedStr := strings.TrimSpace(str)
-	splitLines := strings.SplitN(trimmedStr, ",", 4)
+// parseVagrantOutputLine parses any machine readable line, including the `metadata`, `ui` and `action` lines
+// that are ignored by `vagrantOutputLineFromString`. Fields are slices of `str`, so that it's not copied.
+func parseVagrantOutputLine(str string) *vagrantOutputLine {
+	rest := strings.TrimSpace(str)
+	fields := [3]string{}
 
-	//noinspection GoPreferNilSlice
-	vagrantLines := []string{}
+	for i := range fields {
+		index := strings.IndexByte(rest, ',')
+		if index < 0 {
+			return nil
+		}
 
-	for _, line := range splitLines {
-		if contains(ignoredOutputLines, line) {
-			continue
+		fields[i] = rest[:index]
+		rest = rest[index+1:]
+	}
+
+	return &vagrantOutputLine{
+		timestamp: fields[0],
+		target:    fields[1],
+		kind:      fields[2],
+		data:      splitOutputLineData(rest),
+	}
+}
+
+// splitOutputLineData splits data fields of a line with a single allocation.
+func splitOutputLineData(str string) []string {
+	data := make([]string, 0, strings.Count(str, ",")+1)
+
+	for {
+		index := strings.IndexByte(str, ',')
+		if index < 0 {
+			break
 		}
 
-		vagrantLines = append(vagrantLines, line)
+		data = append(data, str[:index])
+		str = str[index+1:]
 	}
 
-	if len(vagrantLines) < 4 {
+	return append(data, str)
+}
+
+func vagrantOutputLineFromString(str string) *vagrantOutputLine {
+	line := parseVagrantOutputLine(str)
+	if line == nil || isIgnoredOutputLine(line) {
 		return nil
 	}
 
-	//noinspection GoPreferNilSlice
-	dataLines := []string{}
+	return line
+}
 
-	for _, line := range strings.Split(vagrantLines[3], ",") {
-		dataLines = append(dataLines, line)
+// isIgnoredOutputLine returns whether any field of `line` is one of `ignoredOutputLines`. Data is checked as a whole.
+func isIgnoredOutputLine(line *vagrantOutputLine) bool {
+	if contains(ignoredOutputLines, line.timestamp) ||
+		contains(ignoredOutputLines, line.target) ||
+		contains(ignoredOutputLines, line.kind) {
+		return true
 	}
 
-	return &vagrantOutputLine{
-		timestamp: vagrantLines[0],
-		target:    vagrantLines[1],
-		kind:      vagrantLines[2],
-		data:      dataLines,
+	return len(line.data) == 1 && contains(ignoredOutputLines, line.data[0])
+}
+
+// NOTE: Vagrant escapes commas and newlines in data fields of machine readable output.
+// ref: https://www.vagrantup.com/docs/cli/machine-readable.html
+var machineReadableUnescaper = strings.NewReplacer(
+	"%!(VAGRANT_COMMA)", ",",
+	`\n`, "\n",
+	`\r`, "\r",
+)
+
+func unescapeMachineReadable(str string) string {
+	return machineReadableUnescaper.Replace(str)
+}
+
+// findErrorExit returns the error class and unescaped message of the `error-exit` line, that Vagrant prints before
+// exiting with an error.
+func findErrorExit(lines []*vagrantOutputLine) (errorClass string, message string, found bool) {
+	for _, line := range lines {
+		if line.kind != "error-exit" || len(line.data) < 2 {
+			continue
+		}
+
+		return line.data[0], unescapeMachineReadable(line.data[1]), true
+	}
+
+	return "", "", false
+}
+
+// OutputLine is a decoded line of machine readable output.
+type OutputLine struct {
+	// Timestamp has a resolution of one second. It's zero, if the line has an invalid timestamp.
+	Timestamp time.Time
+	// Target is the name of the machine the line is about. It's empty for lines about the whole environment.
+	Target string
+	// Kind is the type of the line, e.g. `state`, `ui` or `error-exit`.
+	Kind string
+	// Data are the fields of the line with escaped commas and newlines decoded.
+	Data []string
+}
+
+func newOutputLine(line *vagrantOutputLine) *OutputLine {
+	data := make([]string, 0, len(line.data))
+
+	for _, field := range line.data {
+		data = append(data, unescapeMachineReadable(field))
+	}
+
+	return &OutputLine{
+		Timestamp: parseOutputLineTimestamp(line.timestamp),
+		Target:    line.target,
+		Kind:      line.kind,
+		Data:      data,
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func TestVagrantOutputLineFromString(t *testing.T) {
@@ -86,3 +87,96 @@ func TestVagrantOutputLineFromString(t *testing.T) {
 		},
 	)
 }
+
+func TestUnescapeMachineReadable(t *testing.T) {
+	t.Run(
+		"with escaped commas and newlines, it returns unescaped string",
+		func(t *testing.T) {
+			t.Parallel()
+
+			actual := unescapeMachineReadable(`vm:\n* foo%!(VAGRANT_COMMA) bar\r\n`)
+			assert.Equal(t, "vm:\n* foo, bar\r\n", actual)
+		},
+	)
+
+	t.Run(
+		"with no escaped characters, it returns same string",
+		func(t *testing.T) {
+			t.Parallel()
+
+			actual := unescapeMachineReadable("running")
+			assert.Equal(t, "running", actual)
+		},
+	)
+}
+
+func TestParseVagrantOutputLine(t *testing.T) {
+	t.Run(
+		"with machine readable output that contains a `ui` line, it returns parsed line",
+		func(t *testing.T) {
+			t.Parallel()
+
+			line := parseVagrantOutputLine(`1546430404,,ui,info,Uploading file setup.sh to /tmp/setup.sh`)
+			require.NotNil(t, line)
+
+			assert.Equal(t, "1546430404", line.timestamp)
+			assert.Equal(t, "", line.target)
+			assert.Equal(t, "ui", line.kind)
+			assert.Equal(t, []string{"info", "Uploading file setup.sh to /tmp/setup.sh"}, line.data)
+		},
+	)
+
+	t.Run(
+		"with blank output, it returns nil",
+		func(t *testing.T) {
+			t.Parallel()
+
+			line := parseVagrantOutputLine("")
+			assert.Nil(t, line)
+		},
+	)
+}
+
+func TestFindErrorExit(t *testing.T) {
+	t.Run(
+		"with an `error-exit` line, it returns its error class and unescaped message",
+		func(t *testing.T) {
+			t.Parallel()
+
+			lines := []*vagrantOutputLine{
+				parseVagrantOutputLine(`1546430404,,ui,error,Something went wrong`),
+				parseVagrantOutputLine(`1546430404,,error-exit,Vagrant::Errors::VMNotCreatedError,The machine is not created%!(VAGRANT_COMMA) yet.\nRun up.`),
+			}
+
+			errorClass, message, found := findErrorExit(lines)
+			assert.True(t, found)
+			assert.Equal(t, "Vagrant::Errors::VMNotCreatedError", errorClass)
+			assert.Equal(t, "The machine is not created, yet.\nRun up.", message)
+		},
+	)
+
+	t.Run(
+		"with no `error-exit` line, it returns not found",
+		func(t *testing.T) {
+			t.Parallel()
+
+			_, _, found := findErrorExit([]*vagrantOutputLine{})
+			assert.False(t, found)
+		},
+	)
+}
+
+func TestNewOutputLine(t *testing.T) {
+	t.Parallel()
+
+	line := parseVagrantOutputLine(`1546430404,default,state-human-long,To stop this machine%!(VAGRANT_COMMA) run\n'vagrant halt'.`)
+	require.NotNil(t, line)
+
+	outputLine := newOutputLine(line)
+	assert.Equal(t, &OutputLine{
+		Timestamp: time.Unix(1546430404, 0),
+		Target:    "default",
+		Kind:      "state-human-long",
+		Data:      []string{"To stop this machine, run\n'vagrant halt'."},
+	}, outputLine)
+}
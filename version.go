@@ -0,0 +1,154 @@
+package vagrant_go
+
+import (
+	"fmt"
+	"github.com/palantir/stacktrace"
+	"strconv"
+	"strings"
+)
+
+// Version is a Vagrant release version.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ParseVersion parses versions in format of `2.2.3`. Pre-release suffixes, e.g. `.dev`, are ignored.
+func ParseVersion(str string) (*Version, error) {
+	parts := strings.SplitN(strings.TrimSpace(str), ".", 4)
+	if len(parts) < 3 {
+		return nil, stacktrace.NewError("invalid version `%s`", str)
+	}
+
+	numbers := make([]int, 3)
+
+	for i := range numbers {
+		number, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "invalid version `%s`", str)
+		}
+
+		numbers[i] = number
+	}
+
+	return &Version{
+		Major: numbers[0],
+		Minor: numbers[1],
+		Patch: numbers[2],
+	}, nil
+}
+
+// AtLeast returns whether the version is the same or newer than `other`.
+func (v *Version) AtLeast(other *Version) bool {
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+
+	if v.Minor != other.Minor {
+		return v.Minor > other.Minor
+	}
+
+	return v.Patch >= other.Patch
+}
+
+func (v *Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// capability is a command or flag, that only exists since a Vagrant release.
+type capability struct {
+	name       string
+	minVersion *Version
+}
+
+var (
+	capabilityInstallProvider = &capability{
+		name:       "`up --[no-]install-provider`",
+		minVersion: &Version{Major: 2, Minor: 0, Patch: 0},
+	}
+	capabilityUpload = &capability{
+		name:       "`upload`",
+		minVersion: &Version{Major: 2, Minor: 2, Patch: 0},
+	}
+	capabilityWinrmConfig = &capability{
+		name:       "`winrm-config`",
+		minVersion: &Version{Major: 2, Minor: 2, Patch: 0},
+	}
+)
+
+// Version returns the installed Vagrant version. It's detected on the first call or the first command that depends
+// on the version, and cached for the lifetime of the client once detection succeeds.
+func (c *Client) Version() (*Version, error) {
+	c.versionMutex.Lock()
+	defer c.versionMutex.Unlock()
+
+	if c.version != nil {
+		return c.version, nil
+	}
+
+	version, err := c.detectVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	c.version = version
+	return version, nil
+}
+
+func (c *Client) detectVersion() (*Version, error) {
+	outputLines, err := c.executeVagrantCommand("version")
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "command execution failed")
+	}
+
+	for _, line := range outputLines {
+		if line.kind != "version-installed" || len(line.data) < 1 {
+			continue
+		}
+
+		return ParseVersion(line.data[0])
+	}
+
+	return nil, stacktrace.NewError("installed version not reported")
+}
+
+// cachedVersion returns the installed Vagrant version, if it's detected already, or nil otherwise. It never executes
+// anything.
+func (c *Client) cachedVersion() *Version {
+	c.versionMutex.Lock()
+	defer c.versionMutex.Unlock()
+
+	return c.version
+}
+
+// supports returns whether the installed Vagrant version supports `capability`.
+// It's assumed to be supported, when the version is not known.
+func (c *Client) supports(capability *capability) bool {
+	version, _ := c.Version()
+	return capability.isSupportedBy(version)
+}
+
+func (c *Client) requireCapability(capability *capability) error {
+	version, _ := c.Version()
+	return capability.require(version)
+}
+
+// isSupportedBy returns whether `version` supports the capability. It's assumed to be supported by an unknown version.
+func (c *capability) isSupportedBy(version *Version) bool {
+	return version == nil || version.AtLeast(c.minVersion)
+}
+
+func (c *capability) require(version *Version) error {
+	if c.isSupportedBy(version) {
+		return nil
+	}
+
+	return stacktrace.NewErrorWithCode(
+		ErrorCodeUnsupportedVersion,
+		"%s requires Vagrant %s or newer, installed is %s",
+		c.name,
+		c.minVersion,
+		version,
+	)
+}
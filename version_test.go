@@ -0,0 +1,258 @@
+package vagrant_go
+
+import (
+	"errors"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func versionTestClient(t *testing.T, installedVersion string) *Client {
+	commandRunFunc := func(cmd string, args ...string) ([]byte, error) {
+		assert.Equal(t, []string{"--machine-readable", "version"}, args)
+
+		output := "1546430404,,version-installed," + installedVersion + "\n1546430404,,version-latest,2.2.3"
+		return []byte(output), nil
+	}
+
+	client := testClient(t, commandRunFunc, emptyLookPathFunc)
+	// NOTE: Detect the version before tests replace `commandRunFunc`
+	_, _ = client.Version()
+
+	return client
+}
+
+func TestParseVersion(t *testing.T) {
+	t.Run(
+		"with release version, it returns parsed version",
+		func(t *testing.T) {
+			t.Parallel()
+
+			version, err := ParseVersion("2.2.3")
+			require.NoError(t, err)
+			assert.Equal(t, &Version{Major: 2, Minor: 2, Patch: 3}, version)
+			assert.Equal(t, "2.2.3", version.String())
+		},
+	)
+
+	t.Run(
+		"with pre-release version, it returns parsed version without suffix",
+		func(t *testing.T) {
+			t.Parallel()
+
+			version, err := ParseVersion("2.2.4.dev")
+			require.NoError(t, err)
+			assert.Equal(t, &Version{Major: 2, Minor: 2, Patch: 4}, version)
+		},
+	)
+
+	t.Run(
+		"with invalid version, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseVersion("2.x.1")
+			assert.Error(t, err)
+
+			_, err = ParseVersion("2.2")
+			assert.Error(t, err)
+		},
+	)
+}
+
+func TestVersion_AtLeast(t *testing.T) {
+	t.Parallel()
+
+	version := &Version{Major: 2, Minor: 1, Patch: 5}
+
+	assert.True(t, version.AtLeast(&Version{Major: 2, Minor: 1, Patch: 5}))
+	assert.True(t, version.AtLeast(&Version{Major: 2, Minor: 1, Patch: 4}))
+	assert.True(t, version.AtLeast(&Version{Major: 1, Minor: 9, Patch: 8}))
+	assert.False(t, version.AtLeast(&Version{Major: 2, Minor: 1, Patch: 6}))
+	assert.False(t, version.AtLeast(&Version{Major: 2, Minor: 2, Patch: 0}))
+	assert.False(t, version.AtLeast(&Version{Major: 3, Minor: 0, Patch: 0}))
+}
+
+func TestClient_Version(t *testing.T) {
+	t.Run(
+		"with `version-installed` reported, it returns the installed version",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := versionTestClient(t, "2.1.5")
+
+			version, err := client.Version()
+			require.NoError(t, err)
+			assert.Equal(t, &Version{Major: 2, Minor: 1, Patch: 5}, version)
+		},
+	)
+
+	t.Run(
+		"with new client, it detects the version once, on first use",
+		func(t *testing.T) {
+			t.Parallel()
+
+			calls := 0
+			commandRunFunc := func(cmd string, args ...string) ([]byte, error) {
+				assert.Equal(t, []string{"--machine-readable", "version"}, args)
+				calls++
+
+				return []byte("1546430404,,version-installed,2.2.3"), nil
+			}
+
+			client := testClient(t, commandRunFunc, emptyLookPathFunc)
+			assert.Equal(t, 0, calls)
+
+			assert.True(t, client.supports(capabilityUpload))
+			assert.Equal(t, 1, calls)
+
+			version, err := client.Version()
+			require.NoError(t, err)
+			assert.Equal(t, &Version{Major: 2, Minor: 2, Patch: 3}, version)
+			assert.Equal(t, 1, calls)
+		},
+	)
+
+	t.Run(
+		"with version command failing first, it detects the version again on next use",
+		func(t *testing.T) {
+			t.Parallel()
+
+			calls := 0
+			commandRunFunc := func(cmd string, args ...string) ([]byte, error) {
+				calls++
+				if calls == 1 {
+					return []byte{}, errors.New("fake error")
+				}
+
+				return []byte("1546430404,,version-installed,2.1.5"), nil
+			}
+
+			client := testClient(t, commandRunFunc, emptyLookPathFunc)
+
+			_, err := client.Version()
+			assert.Error(t, err)
+
+			err = client.requireCapability(capabilityUpload)
+			assert.Equal(t, ErrorCodeUnsupportedVersion, stacktrace.GetCode(err))
+			assert.Equal(t, 2, calls)
+		},
+	)
+
+	t.Run(
+		"with version command failing, it returns an error and does not gate capabilities",
+		func(t *testing.T) {
+			t.Parallel()
+
+			commandRunFunc := func(cmd string, args ...string) ([]byte, error) {
+				return []byte{}, errors.New("fake error")
+			}
+			client := testClient(t, commandRunFunc, emptyLookPathFunc)
+
+			version, err := client.Version()
+			assert.Nil(t, version)
+			assert.Error(t, err)
+
+			assert.True(t, client.supports(capabilityUpload))
+			assert.NoError(t, client.requireCapability(capabilityUpload))
+		},
+	)
+}
+
+func TestClient_RequireCapability(t *testing.T) {
+	t.Run(
+		"with installed version older than required, it returns an error with 'ErrorCodeUnsupportedVersion'",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := versionTestClient(t, "2.1.5")
+
+			err := client.requireCapability(capabilityUpload)
+			require.Error(t, err)
+			assert.Equal(t, ErrorCodeUnsupportedVersion, stacktrace.GetCode(err))
+			assert.Contains(t, err.Error(), "`upload` requires Vagrant 2.2.0 or newer, installed is 2.1.5")
+		},
+	)
+
+	t.Run(
+		"with installed version same as required, it returns no error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := versionTestClient(t, "2.2.0")
+
+			err := client.requireCapability(capabilityUpload)
+			assert.NoError(t, err)
+		},
+	)
+}
+
+func TestGlobalAPI_VersionGating(t *testing.T) {
+	t.Run(
+		"with installed version not supporting `--install-provider`, Up executes command without it",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := versionTestClient(t, "1.9.8")
+			isCommandRunCalled := false
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{
+					"--machine-readable",
+					"up",
+					"--provision",
+					"--destroy-on-error",
+					"--parallel",
+				}, args)
+
+				isCommandRunCalled = true
+				return []byte{}, nil
+			}
+
+			_, err := client.Global.Up(DefaultUpOptions())
+			require.NoError(t, err)
+			assert.True(t, isCommandRunCalled)
+		},
+	)
+
+	t.Run(
+		"with installed version not supporting `upload`, Upload does not execute command and returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := versionTestClient(t, "2.1.5")
+			isCommandRunCalled := false
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				isCommandRunCalled = true
+				return []byte{}, nil
+			}
+
+			_, err := client.Global.Upload("web", "/tmp/setup.sh", "", DefaultUploadOptions())
+			require.Error(t, err)
+			assert.Equal(t, ErrorCodeUnsupportedVersion, stacktrace.GetCode(err))
+			assert.False(t, isCommandRunCalled)
+		},
+	)
+
+	t.Run(
+		"with installed version not supporting `winrm-config`, WinrmConfig does not execute command and returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := versionTestClient(t, "2.1.5")
+			isCommandRunCalled := false
+
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				isCommandRunCalled = true
+				return []byte{}, nil
+			}
+
+			_, err := client.Global.WinrmConfig(DefaultWinrmConfigOptions())
+			require.Error(t, err)
+			assert.Equal(t, ErrorCodeUnsupportedVersion, stacktrace.GetCode(err))
+			assert.False(t, isCommandRunCalled)
+		},
+	)
+}
@@ -0,0 +1,65 @@
+package vagrant_go
+
+import (
+	"context"
+	"github.com/palantir/stacktrace"
+	"time"
+)
+
+// WaitForState polls the status of `machine` every `interval` until it reaches one of raw `desiredStates`, e.g.
+// `running` or `poweroff`, and returns the final observed state. When `ctx` is done first, it gives up with an error
+// and returns the last observed state. The error has `ErrorCodeWaitTimeout` only when the deadline of `ctx` passed,
+// not when `ctx` is canceled.
+func WaitForState(
+	ctx context.Context,
+	machine *Machine,
+	desiredStates []string,
+	interval time.Duration,
+) (string, error) {
+	if len(desiredStates) == 0 {
+		return "", stacktrace.NewError("`desiredStates` must not be empty")
+	}
+
+	if interval <= 0 {
+		return "", stacktrace.NewError("`interval` must be positive, got %s", interval)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var state string
+
+	for {
+		status, err := machine.Status()
+		if err != nil {
+			return state, stacktrace.Propagate(err, "failed to query state of machine `%s`", machine.Name)
+		}
+
+		state = status.State
+		if contains(desiredStates, state) {
+			return state, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if ctx.Err() != context.DeadlineExceeded {
+				return state, stacktrace.Propagate(
+					ctx.Err(),
+					"waiting for machine `%s` canceled, last state is `%s`",
+					machine.Name,
+					state,
+				)
+			}
+
+			return state, stacktrace.PropagateWithCode(
+				ctx.Err(),
+				ErrorCodeWaitTimeout,
+				"machine `%s` did not reach any of states %v, last state is `%s`",
+				machine.Name,
+				desiredStates,
+				state,
+			)
+		case <-ticker.C:
+		}
+	}
+}
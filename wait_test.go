@@ -0,0 +1,133 @@
+package vagrant_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitForState(t *testing.T) {
+	t.Run(
+		"with machine reaching a desired state after polling, it returns the state",
+		func(t *testing.T) {
+			t.Parallel()
+
+			states := []string{"not_created", "preparing", "running"}
+			var calls int32
+
+			client := projectTestClient(t)
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				assert.Equal(t, []string{"--machine-readable", "status", "web"}, args)
+
+				call := atomic.AddInt32(&calls, 1)
+				return []byte(fmt.Sprintf("1546430404,web,state,%s", states[call-1])), nil
+			}
+
+			machine := client.Project("/tmp/example").Machine("web")
+
+			state, err := WaitForState(context.Background(), machine, []string{"running", "poweroff"}, time.Millisecond)
+			require.NoError(t, err)
+
+			assert.Equal(t, "running", state)
+			assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+		},
+	)
+
+	t.Run(
+		"with context done before a desired state is reached, it returns the last state and an error with 'ErrorCodeWaitTimeout'",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := projectTestClient(t)
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				return []byte("1546430404,web,state,preparing"), nil
+			}
+
+			machine := client.Project("/tmp/example").Machine("web")
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			state, err := WaitForState(ctx, machine, []string{"running"}, time.Millisecond)
+			require.Error(t, err)
+
+			assert.Equal(t, "preparing", state)
+			assert.Equal(t, ErrorCodeWaitTimeout, stacktrace.GetCode(err))
+			assert.Contains(t, err.Error(), "last state is `preparing`")
+		},
+	)
+
+	t.Run(
+		"with context canceled before a desired state is reached, it returns the last state and an error without 'ErrorCodeWaitTimeout'",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := projectTestClient(t)
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				return []byte("1546430404,web,state,preparing"), nil
+			}
+
+			machine := client.Project("/tmp/example").Machine("web")
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			state, err := WaitForState(ctx, machine, []string{"running"}, time.Millisecond)
+			require.Error(t, err)
+
+			assert.Equal(t, "preparing", state)
+			assert.NotEqual(t, ErrorCodeWaitTimeout, stacktrace.GetCode(err))
+			assert.Equal(t, context.Canceled, stacktrace.RootCause(err))
+		},
+	)
+
+	t.Run(
+		"with status query returning an error, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			client := projectTestClient(t)
+			client.commandRunFunc = func(cmd string, args ...string) (bytes []byte, e error) {
+				return []byte{}, errors.New("fake error")
+			}
+
+			machine := client.Project("/tmp/example").Machine("web")
+
+			_, err := WaitForState(context.Background(), machine, []string{"running"}, time.Millisecond)
+			require.Error(t, err)
+			assert.NotEqual(t, ErrorCodeWaitTimeout, stacktrace.GetCode(err))
+			assert.Contains(t, err.Error(), "failed to query state of machine `web`")
+		},
+	)
+
+	t.Run(
+		"with no desired states, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			machine := emptyTestClient(t).Project("/tmp/example").Machine("web")
+
+			_, err := WaitForState(context.Background(), machine, []string{}, time.Millisecond)
+			require.Error(t, err)
+		},
+	)
+
+	t.Run(
+		"with non-positive interval, it returns an error",
+		func(t *testing.T) {
+			t.Parallel()
+
+			machine := emptyTestClient(t).Project("/tmp/example").Machine("web")
+
+			_, err := WaitForState(context.Background(), machine, []string{"running"}, 0)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "`interval` must be positive")
+		},
+	)
+}